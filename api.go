@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/opensourceways/community-robot-lib/config"
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// sigInfo is the sig and its members returned by the api.
+type sigInfo struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Link        string   `json:"link,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	Repos       []string `json:"repos,omitempty"`
+	Owners      []string `json:"owners"`
+	Maintainers []string `json:"maintainers"`
+	Committers  []string `json:"committers"`
+}
+
+func newSigInfo(g *sigData) sigInfo {
+	return sigInfo{
+		Name:        g.Name,
+		Label:       g.Label,
+		Link:        g.Link,
+		Files:       g.Files,
+		Owners:      g.Owners,
+		Maintainers: g.Maintainers,
+		Committers:  g.Committers,
+	}
+}
+
+// apiServer serves the read-only api which resolves the sigs and owners in the same way as the robot,
+// with the relationship data cached by the robot.
+type apiServer struct {
+	bot   *robot
+	agent *config.ConfigAgent
+}
+
+func newAPIServer(bot *robot, agent *config.ConfigAgent) *apiServer {
+	return &apiServer{bot: bot, agent: agent}
+}
+
+func (s *apiServer) register(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/resolve", s.handleResolve)
+	mux.HandleFunc("/v1/sigs/", s.handleSig)
+}
+
+// getConfig returns the config of the repository, or the first one if neither org nor repo is given.
+func (s *apiServer) getConfig(org, repo string) (*botConfig, error) {
+	_, cfg := s.agent.GetConfig()
+	if org != "" && repo != "" {
+		return s.bot.getConfig(cfg, org, repo)
+	}
+
+	c, ok := cfg.(*configuration)
+	if !ok || c == nil || len(c.ConfigItems) == 0 {
+		return nil, errors.New("no config")
+	}
+
+	return &c.ConfigItems[0], nil
+}
+
+// handleResolve handles /v1/resolve?org=&repo=&file=, in which file can be repeated.
+// It returns the sigs of the files and their owners as the pull request of them,
+// or the sig of the repository as the issue if no file is given.
+func (s *apiServer) handleResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "only GET is allowed")
+
+		return
+	}
+
+	q := r.URL.Query()
+	org, repo := q.Get("org"), q.Get("repo")
+	if org == "" || repo == "" {
+		writeAPIError(w, http.StatusBadRequest, "missing org or repo")
+
+		return
+	}
+
+	bc, err := s.getConfig(org, repo)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+
+		return
+	}
+
+	var changes []sdk.PullRequestFiles
+	for _, f := range q["file"] {
+		changes = append(changes, sdk.PullRequestFiles{Filename: f})
+	}
+
+	labels, err := s.bot.sigLabelsOfChanges(bc, repo, changes)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	guides, err := s.bot.genSigGuides(bc, org, repo, changes, labels)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	sigs := make([]sigInfo, 0, len(guides))
+	for i := range guides {
+		sigs = append(sigs, newSigInfo(&guides[i]))
+	}
+
+	writeAPIResult(w, map[string]interface{}{"sigs": sigs})
+}
+
+// handleSig handles /v1/sigs/<name>?org=&repo=, in which name can be the name, label or alias of the sig.
+// The owners and the customized members are the ones of the repository, if both org and repo are given.
+func (s *apiServer) handleSig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "only GET is allowed")
+
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/v1/sigs/")
+	if name == "" {
+		writeAPIError(w, http.StatusBadRequest, "missing name of sig")
+
+		return
+	}
+
+	q := r.URL.Query()
+	org, repo := q.Get("org"), q.Get("repo")
+	if (org == "") != (repo == "") {
+		writeAPIError(w, http.StatusBadRequest, "org and repo must be given together")
+
+		return
+	}
+
+	bc, err := s.getConfig(org, repo)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+
+		return
+	}
+
+	sigs, err := s.bot.decodeSigsContent(&bc.RelationSource)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	label := sigs.resolveLabel(name)
+	if label == "" {
+		writeAPIError(w, http.StatusNotFound, "unknown sig: "+name)
+
+		return
+	}
+
+	g, err := s.bot.genSigGuide(bc, org, repo, label, nil)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	info := newSigInfo(&g)
+	if repo == "" {
+		// there are only the default owners without the repository.
+		info.Owners = nil
+	}
+
+	repos := sets.NewString()
+	for i := range sigs.Sigs {
+		if sigs.Sigs[i].SigLabel != label {
+			continue
+		}
+
+		for _, rm := range sigs.Sigs[i].Repos {
+			repos.Insert(rm.Repo...)
+		}
+	}
+	info.Repos = repos.List()
+
+	writeAPIResult(w, info)
+}
+
+func writeAPIResult(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
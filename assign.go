@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type assignConfig struct {
+	// Issue means assigning the issue to its first contact
+	Issue bool `json:"issue,omitempty"`
+
+	// PR means assigning the pull request to its first contacts
+	PR bool `json:"pr,omitempty"`
+
+	// PRReviewers is the number of maintainers of each sig assigned to the pull request.
+	// The assignees of a pull request on gitee are its reviewers.
+	PRReviewers int `json:"pr_reviewers,omitempty"`
+}
+
+func (c *assignConfig) validate() error {
+	if c.PRReviewers < 0 {
+		return errors.New("pr_reviewers of assign must not be negative")
+	}
+
+	return nil
+}
+
+// assignIssue assigns the issue to the first contact picked for the message, unless it has an assignee,
+// since an issue has only one assignee and it may be chosen by hand.
+func (bot *robot) assignIssue(bc *botConfig, org, repo, number string, data *messageData) error {
+	if !bc.Assign.Issue || len(data.Owners) == 0 {
+		return nil
+	}
+
+	issue, err := bot.cli.GetIssue(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	if userLogin(issue.Assignee) != "" {
+		return nil
+	}
+
+	return bot.cli.AssignGiteeIssue(org, repo, number, data.Owners[0])
+}
+
+// assignPR assigns the pull request to the first contacts picked for the message,
+// and some maintainers of each sig as its reviewers. The reviewers assigned by the robot before
+// are kept if their sigs are still in the message, and unassigned otherwise.
+// The assignees added by hand are left alone.
+func (bot *robot) assignPR(bc *botConfig, org, repo string, number int32, data *messageData) error {
+	key := prKey(org, repo, number)
+
+	var previous sets.String
+	bot.store.view(func(d *stateData) {
+		previous = sets.NewString(d.BotAssignees[key]...)
+	})
+
+	assignees := sets.NewString()
+	if bc.Assign.PR {
+		assignees.Insert(data.Owners...)
+	}
+
+	// the reviewers are picked from all the maintainers, since the ones in the message are capped for mentions.
+	if n := bc.Assign.PRReviewers; n > 0 {
+		for i := range data.Sigs {
+			maintainers := sets.NewString(data.Sigs[i].allMaintainers...).Delete(data.Author).List()
+			assignees.Insert(bot.pickReviewers(maintainers, previous, n)...)
+		}
+	}
+
+	if v := previous.Difference(assignees); len(v) > 0 {
+		if err := bot.cli.UnassignPR(org, repo, number, v.List()); err != nil {
+			return err
+		}
+	}
+
+	if v := assignees.Difference(previous); len(v) > 0 {
+		if err := bot.cli.AssignPR(org, repo, number, v.List()); err != nil {
+			return err
+		}
+	}
+
+	if assignees.Equal(previous) {
+		return nil
+	}
+
+	return bot.store.update(func(d *stateData) {
+		if d.BotAssignees == nil {
+			d.BotAssignees = make(map[string][]string)
+		}
+
+		d.BotAssignees[key] = assignees.List()
+	})
+}
+
+// pickReviewers picks n of the maintainers, the ones assigned before go first,
+// then the ones who have the fewest open items routed to them.
+func (bot *robot) pickReviewers(maintainers []string, assigned sets.String, n int) []string {
+	if len(maintainers) <= n {
+		return maintainers
+	}
+
+	v := append([]string{}, maintainers...)
+	bot.store.view(func(d *stateData) {
+		sort.SliceStable(v, func(i, j int) bool {
+			if a, b := assigned.Has(v[i]), assigned.Has(v[j]); a != b {
+				return a
+			}
+
+			return d.workload(v[i]) < d.workload(v[j])
+		})
+	})
+
+	return v[:n]
+}
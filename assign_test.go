@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAssignPRPicksReviewersFromAllMaintainers(t *testing.T) {
+	cli := &fakeClient{}
+	bot, bc := newTestRobot(t, cli, nil)
+	bc.MentionLimit = 2
+	bc.Assign.PRReviewers = 1
+
+	// m1 and m2 are busy, m3 is not mentioned because of mention_limit but is the least loaded.
+	bot.store.data.Assigned = map[string][]string{
+		"m1": {"o/r!2"},
+		"m2": {"o/r!3"},
+	}
+
+	maintainers := []string{"m1", "m2", "m3", "owner"}
+	data := &messageData{
+		Author: "author",
+		Owners: []string{"owner"},
+		Sigs: []sigData{{
+			Name: "storage", Label: "sig/storage", Owners: []string{"owner"},
+			Maintainers: maintainers, allMaintainers: maintainers,
+		}},
+		item: prKey("o", "r", 1),
+	}
+
+	if _, err := bot.genMessage(bc, tmplPRGuide, "", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bot.assignPR(bc, "o", "r", 1, data); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLabels(t, "assigned", cli.assigned, "m3")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// relationCache keeps the parsed relationship data(gauss_relationship.yaml and the OWNERS of sigs)
+// in memory, so that one event does not fetch the same file from gitee several times.
+type relationCache struct {
+	ttl time.Duration
+
+	lock    sync.RWMutex
+	entries map[string]cacheEntry
+
+	// loading are the keys being loaded, and generation is increased by each invalidation which drops
+	// any of them or of the entries, so that a value loaded before it is not stored.
+	loading    map[string]int
+	generation uint64
+}
+
+type cacheEntry struct {
+	value     interface{}
+	fetchedAt time.Time
+}
+
+// newRelationCache returns a cache whose entries expire after ttl.
+// A ttl which is not positive disables the cache.
+func newRelationCache(ttl time.Duration) *relationCache {
+	return &relationCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		loading: make(map[string]int),
+	}
+}
+
+// get returns the value cached for the key, and calls load to refresh it
+// when it is missing or expired.
+func (c *relationCache) get(key string, load func() (interface{}, error)) (interface{}, error) {
+	if c.ttl <= 0 {
+		return load()
+	}
+
+	c.lock.Lock()
+	if e, ok := c.entries[key]; ok && time.Since(e.fetchedAt) < c.ttl {
+		c.lock.Unlock()
+
+		return e.value, nil
+	}
+
+	c.loading[key]++
+	generation := c.generation
+	c.lock.Unlock()
+
+	v, err := load()
+
+	c.lock.Lock()
+	if c.loading[key]--; c.loading[key] == 0 {
+		delete(c.loading, key)
+	}
+
+	if err == nil && generation == c.generation {
+		c.entries[key] = cacheEntry{value: v, fetchedAt: time.Now()}
+	}
+	c.lock.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// invalidate drops the cached entries whose key starts with the prefix,
+// and the values of such keys being loaded are not stored.
+func (c *relationCache) invalidate(prefix string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k := range c.loading {
+		if strings.HasPrefix(k, prefix) {
+			c.generation++
+
+			break
+		}
+	}
+
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// ages returns how long ago each cached entry was fetched.
+func (c *relationCache) ages() map[string]time.Duration {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	r := make(map[string]time.Duration, len(c.entries))
+	for k, e := range c.entries {
+		r[k] = time.Since(e.fetchedAt)
+	}
+
+	return r
+}
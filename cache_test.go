@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelationCacheInvalidatedWhileLoading(t *testing.T) {
+	cases := []struct {
+		name   string
+		prefix string
+		cached bool
+	}{
+		{name: "push to the relation source", prefix: cachePrefix("o", "tc"), cached: false},
+		{name: "push to another repository", prefix: cachePrefix("o", "r"), cached: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cache := newRelationCache(time.Hour)
+			key := cachePrefix("o", "tc") + "master:sigs:a.yaml"
+
+			_, err := cache.get(key, func() (interface{}, error) {
+				cache.invalidate(c.prefix)
+
+				return "old", nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := cache.ages()[key]; ok != c.cached {
+				t.Errorf("cached: got %v, want %v", ok, c.cached)
+			}
+		})
+	}
+}
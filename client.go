@@ -0,0 +1,366 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+)
+
+var (
+	// statusRegex matches the status line of the response, e.g. 404 Not Found,
+	// which is the message of sdk.GenericSwaggerError.
+	statusRegex = regexp.MustCompile(`^(\d{3})\b`)
+
+	// giteeclientErrRegex matches the errors of giteeclient, which formats the sdk error
+	// as "Failed to <do what>, err: <status line>, <body>".
+	giteeclientErrRegex = regexp.MustCompile(`^Failed to .*?, err: (\d{3}) `)
+)
+
+var defaultClientOptions = clientOptions{
+	qps:        5,
+	burst:      10,
+	maxRetries: 3,
+	retryBase:  500 * time.Millisecond,
+}
+
+type clientOptions struct {
+	// qps is the rate of requests to gitee, not positive means no limit
+	qps float64
+
+	// burst is the max number of requests sent at once
+	burst int
+
+	// maxRetries is the max number of retries of a failed request
+	maxRetries int
+
+	// retryBase is the backoff of the first retry, it doubles for each of the following ones
+	retryBase time.Duration
+}
+
+// limitedClient wraps iClient with a token-bucket rate limiter and retries with exponential backoff.
+type limitedClient struct {
+	cli     iClient
+	opt     clientOptions
+	limiter *tokenBucket
+}
+
+func newLimitedClient(cli iClient, opt clientOptions) *limitedClient {
+	return &limitedClient{
+		cli:     cli,
+		opt:     opt,
+		limiter: newTokenBucket(opt.qps, opt.burst),
+	}
+}
+
+// do sends the request of the method by f, and retries it if failed with 429 or 5xx.
+// It is only for the reads and the idempotent writes.
+func (c *limitedClient) do(method string, f func() error) error {
+	return c.send(method, c.opt.maxRetries, f)
+}
+
+// doOnce sends the request of the method by f without retrying. It is for the writes which create
+// something, since they may have been done even if 5xx is returned.
+func (c *limitedClient) doOnce(method string, f func() error) error {
+	return c.send(method, 0, f)
+}
+
+func (c *limitedClient) send(method string, maxRetries int, f func() error) error {
+	var err error
+	for i := 0; ; i++ {
+		c.limiter.wait()
+
+		start := time.Now()
+		err = f()
+		botMetrics.observeGitee(method, start, err)
+
+		if err == nil || i >= maxRetries || !isRetryable(statusOfErr(err)) {
+			return err
+		}
+
+		backoff := c.opt.retryBase << uint(i)
+		if backoff > 0 {
+			backoff += time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+		}
+
+		time.Sleep(backoff)
+	}
+}
+
+// statusOfErr returns the http status code of the failed request, or 0 if it is unknown,
+// e.g. the request is not sent.
+func statusOfErr(err error) int {
+	var m []string
+
+	var e sdk.GenericSwaggerError
+	if errors.As(err, &e) {
+		m = statusRegex.FindStringSubmatch(e.Error())
+	} else {
+		m = giteeclientErrRegex.FindStringSubmatch(err.Error())
+	}
+
+	if len(m) < 2 {
+		return 0
+	}
+
+	v, _ := strconv.Atoi(m[1])
+
+	return v
+}
+
+// isRetryable reports whether the request is worth retrying, that is 429 Too Many Requests and 5xx.
+func isRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+func (c *limitedClient) CreatePRComment(owner, repo string, number int32, comment string) error {
+	return c.doOnce("CreatePRComment", func() error {
+		return c.cli.CreatePRComment(owner, repo, number, comment)
+	})
+}
+
+func (c *limitedClient) UpdatePRComment(org, repo string, commentID int32, comment string) error {
+	return c.do("UpdatePRComment", func() error {
+		return c.cli.UpdatePRComment(org, repo, commentID, comment)
+	})
+}
+
+func (c *limitedClient) ListPRComments(org, repo string, number int32) (r []sdk.PullRequestComments, err error) {
+	err = c.do("ListPRComments", func() (err error) {
+		r, err = c.cli.ListPRComments(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) CreateIssueComment(owner, repo string, number string, comment string) error {
+	return c.doOnce("CreateIssueComment", func() error {
+		return c.cli.CreateIssueComment(owner, repo, number, comment)
+	})
+}
+
+func (c *limitedClient) UpdateIssueComment(org, repo string, commentID int32, comment string) error {
+	return c.do("UpdateIssueComment", func() error {
+		return c.cli.UpdateIssueComment(org, repo, commentID, comment)
+	})
+}
+
+func (c *limitedClient) ListIssueComments(org, repo, number string) (r []sdk.Note, err error) {
+	err = c.do("ListIssueComments", func() (err error) {
+		r, err = c.cli.ListIssueComments(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) GetBot() (r sdk.User, err error) {
+	err = c.do("GetBot", func() (err error) {
+		r, err = c.cli.GetBot()
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) GetIssueLabels(org, repo, number string) (r []sdk.Label, err error) {
+	err = c.do("GetIssueLabels", func() (err error) {
+		r, err = c.cli.GetIssueLabels(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) RemoveIssueLabel(org, repo, number, label string) error {
+	return c.do("RemoveIssueLabel", func() error {
+		return c.cli.RemoveIssueLabel(org, repo, number, label)
+	})
+}
+
+func (c *limitedClient) GetPullRequestChanges(org, repo string, number int32) (r []sdk.PullRequestFiles, err error) {
+	err = c.do("GetPullRequestChanges", func() (err error) {
+		r, err = c.cli.GetPullRequestChanges(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) GetPRLabels(org, repo string, number int32) (r []sdk.Label, err error) {
+	err = c.do("GetPRLabels", func() (err error) {
+		r, err = c.cli.GetPRLabels(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) AddMultiPRLabel(org, repo string, number int32, label []string) error {
+	return c.do("AddMultiPRLabel", func() error {
+		return c.cli.AddMultiPRLabel(org, repo, number, label)
+	})
+}
+
+func (c *limitedClient) GetPathContent(org, repo, path, ref string) (r sdk.Content, err error) {
+	err = c.do("GetPathContent", func() (err error) {
+		r, err = c.cli.GetPathContent(org, repo, path, ref)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) AddMultiIssueLabel(org, repo, number string, label []string) error {
+	return c.do("AddMultiIssueLabel", func() error {
+		return c.cli.AddMultiIssueLabel(org, repo, number, label)
+	})
+}
+
+func (c *limitedClient) RemovePRLabels(org, repo string, number int32, labels []string) error {
+	return c.do("RemovePRLabels", func() error {
+		return c.cli.RemovePRLabels(org, repo, number, labels)
+	})
+}
+
+func (c *limitedClient) AssignGiteeIssue(org, repo string, number string, login string) error {
+	return c.do("AssignGiteeIssue", func() error {
+		return c.cli.AssignGiteeIssue(org, repo, number, login)
+	})
+}
+
+func (c *limitedClient) AssignPR(owner, repo string, number int32, logins []string) error {
+	return c.do("AssignPR", func() error {
+		return c.cli.AssignPR(owner, repo, number, logins)
+	})
+}
+
+func (c *limitedClient) UnassignPR(owner, repo string, number int32, logins []string) error {
+	return c.do("UnassignPR", func() error {
+		return c.cli.UnassignPR(owner, repo, number, logins)
+	})
+}
+
+func (c *limitedClient) IsCollaborator(owner, repo, login string) (r bool, err error) {
+	err = c.do("IsCollaborator", func() (err error) {
+		r, err = c.cli.IsCollaborator(owner, repo, login)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) GetIssue(org, repo, number string) (r sdk.Issue, err error) {
+	err = c.do("GetIssue", func() (err error) {
+		r, err = c.cli.GetIssue(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) GetGiteePullRequest(org, repo string, number int32) (r sdk.PullRequest, err error) {
+	err = c.do("GetGiteePullRequest", func() (err error) {
+		r, err = c.cli.GetGiteePullRequest(org, repo, number)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) CreateIssue(org, repo, title, body string) (r sdk.Issue, err error) {
+	err = c.doOnce("CreateIssue", func() (err error) {
+		r, err = c.cli.CreateIssue(org, repo, title, body)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) CreateFile(org, repo, branch, path, content, commitMsg string) (r sdk.CommitContent, err error) {
+	err = c.doOnce("CreateFile", func() (err error) {
+		r, err = c.cli.CreateFile(org, repo, branch, path, content, commitMsg)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) GetRepoLabels(owner, repo string) (r []sdk.Label, err error) {
+	err = c.do("GetRepoLabels", func() (err error) {
+		r, err = c.cli.GetRepoLabels(owner, repo)
+		return
+	})
+
+	return
+}
+
+func (c *limitedClient) CreateRepoLabel(org, repo, label, color string) error {
+	return c.doOnce("CreateRepoLabel", func() error {
+		return c.cli.CreateRepoLabel(org, repo, label, color)
+	})
+}
+
+func (c *limitedClient) UpdateRepoLabel(org, repo, oldLabel, name, color string) error {
+	return c.do("UpdateRepoLabel", func() error {
+		return c.cli.UpdateRepoLabel(org, repo, oldLabel, name, color)
+	})
+}
+
+// tokenBucket is a token-bucket rate limiter. A request takes a token,
+// and waits if there is none until it is refilled.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a limiter which allows rate requests per second and burst requests at once.
+// A rate which is not positive means no limit.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) wait() {
+	if b.rate <= 0 {
+		return
+	}
+
+	b.lock.Lock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	// take the token in advance, and wait until it is refilled.
+	b.tokens--
+	d := time.Duration(0)
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	b.lock.Unlock()
+
+	time.Sleep(d)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+)
+
+// swaggerError returns the error of the sdk for a response of status.
+func swaggerError(t *testing.T, status int) error {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message":"failed"}`))
+	}))
+	defer srv.Close()
+
+	cfg := sdk.NewConfiguration()
+	cfg.BasePath = srv.URL
+
+	_, _, err := sdk.NewAPIClient(cfg).UsersApi.GetV5User(context.Background(), nil)
+	if !errors.As(err, new(sdk.GenericSwaggerError)) {
+		t.Fatalf("not an error of the sdk: %v", err)
+	}
+
+	return err
+}
+
+func TestStatusOfErr(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    func(t *testing.T) error
+		status int
+	}{
+		{
+			name:   "sdk 429",
+			err:    func(t *testing.T) error { return swaggerError(t, http.StatusTooManyRequests) },
+			status: http.StatusTooManyRequests,
+		},
+		{
+			name:   "sdk 502",
+			err:    func(t *testing.T) error { return swaggerError(t, http.StatusBadGateway) },
+			status: http.StatusBadGateway,
+		},
+		{
+			name:   "sdk 404",
+			err:    func(t *testing.T) error { return swaggerError(t, http.StatusNotFound) },
+			status: http.StatusNotFound,
+		},
+		{
+			name: "wrapped sdk 503",
+			err: func(t *testing.T) error {
+				return fmt.Errorf("get bot: %w", swaggerError(t, http.StatusServiceUnavailable))
+			},
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "giteeclient 429",
+			err: func(*testing.T) error {
+				return errors.New(`Failed to create comment, err: 429 Too Many Requests, {"message":"failed"}`)
+			},
+			status: http.StatusTooManyRequests,
+		},
+		{
+			name: "giteeclient 500",
+			err: func(*testing.T) error {
+				return errors.New("Failed to add label, err: 500 Internal Server Error, ")
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name: "giteeclient 404",
+			err: func(*testing.T) error {
+				return errors.New(`Failed to get issue, err: 404 Not Found, {"message":"Not Found"}`)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "giteeclient without status",
+			err: func(*testing.T) error {
+				return errors.New("Failed to get issue, err: dial tcp: i/o timeout")
+			},
+		},
+		{
+			name: "no status",
+			err:  func(*testing.T) error { return errors.New("connection refused") },
+		},
+		{
+			name: "status not at the beginning",
+			err:  func(*testing.T) error { return errors.New("retry after 429 seconds") },
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.err(t)
+			if got := statusOfErr(err); got != c.status {
+				t.Errorf("statusOfErr(%q) = %d, want %d", err, got, c.status)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := map[int]bool{
+		0:                              false,
+		http.StatusNotFound:            false,
+		http.StatusUnprocessableEntity: false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+	}
+
+	for status, want := range cases {
+		if got := isRetryable(status); got != want {
+			t.Errorf("isRetryable(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	cmdSig       = "sig"
+	cmdRemoveSig = "remove-sig"
+	cmdUnsig     = "unsig"
+)
+
+var (
+	commandRegex = regexp.MustCompile(`^/([a-zA-Z][a-zA-Z-]*)(\s.*)?$`)
+	sigNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+)
+
+// command is a slash command in a comment, such as /sig sqlengine.
+type command struct {
+	name string
+	args []string
+}
+
+// parseCommands finds the commands in the comment. A command must be at the beginning of a line,
+// and the lines in code blocks or quote blocks are ignored. The args can be separated by
+// spaces or commas.
+func parseCommands(comment string) []command {
+	var cmds []command
+	fence := ""
+	for _, line := range strings.Split(strings.ReplaceAll(comment, "\r\n", "\n"), "\n") {
+		// indented code block
+		if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+
+		if fence != "" {
+			if isClosingFence(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		if fence = fenceOf(line); fence != "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+
+		m := commandRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		cmds = append(cmds, command{
+			name: strings.ToLower(m[1]),
+			args: strings.FieldsFunc(m[2], func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t'
+			}),
+		})
+	}
+
+	return cmds
+}
+
+// fenceOf returns the run of backticks or tildes which opens a fenced code block at the beginning of the line,
+// or "" if the line doesn't open one.
+func fenceOf(line string) string {
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return ""
+	}
+
+	n := len(line) - len(strings.TrimLeft(line, line[:1]))
+
+	return line[:n]
+}
+
+// isClosingFence reports whether the line closes the code block opened by fence, which needs
+// a fence of the same character at least as long and nothing else.
+func isClosingFence(line, fence string) bool {
+	v := fenceOf(line)
+
+	return v != "" && v[0] == fence[0] && len(v) >= len(fence) && strings.TrimSpace(line[len(v):]) == ""
+}
+
+// parseSigCommand returns the sig names in the args of the commands named names,
+// and the problems of the malformed ones. found is false if there is no such command.
+func parseSigCommand(comment string, names ...string) (sigNames []string, malformed []string, found bool) {
+	for _, c := range parseCommands(comment) {
+		if !isOneOf(c.name, names) {
+			continue
+		}
+
+		found = true
+
+		if len(c.args) == 0 {
+			malformed = append(malformed, fmt.Sprintf(
+				"`/%s` needs at least one SIG name, for example: `/%s sqlengine`", c.name, c.name,
+			))
+			continue
+		}
+
+		for _, a := range c.args {
+			a = trimSigPrefix(a)
+			if !sigNameRegex.MatchString(a) {
+				malformed = append(malformed, fmt.Sprintf("`%s` of `/%s` is not a valid SIG name", a, c.name))
+				continue
+			}
+
+			sigNames = append(sigNames, a)
+		}
+	}
+
+	return
+}
+
+func isOneOf(s string, items []string) bool {
+	for _, v := range items {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSigCommand(t *testing.T) {
+	cases := []struct {
+		name      string
+		comment   string
+		sigNames  []string
+		malformed int
+		found     bool
+	}{
+		{
+			name:    "no command",
+			comment: "please review it",
+		},
+		{
+			name:      "bare /sig",
+			comment:   "/sig",
+			malformed: 1,
+			found:     true,
+		},
+		{
+			name:      "bare /sig with spaces",
+			comment:   "/sig   ",
+			malformed: 1,
+			found:     true,
+		},
+		{
+			name:     "one sig",
+			comment:  "/sig sqlengine",
+			sigNames: []string{"sqlengine"},
+			found:    true,
+		},
+		{
+			name:     "sig label",
+			comment:  "/sig sig/sqlengine",
+			sigNames: []string{"sqlengine"},
+			found:    true,
+		},
+		{
+			name:     "sig label in upper case",
+			comment:  "/sig SIG/SqlEngine",
+			sigNames: []string{"SqlEngine"},
+			found:    true,
+		},
+		{
+			name:     "on a later line",
+			comment:  "thanks\nthis belongs to\n/sig storage",
+			sigNames: []string{"storage"},
+			found:    true,
+		},
+		{
+			name:    "not at the beginning of the line",
+			comment: "please run /sig storage",
+		},
+		{
+			name:     "comma separated",
+			comment:  "/sig sqlengine,storage, tools",
+			sigNames: []string{"sqlengine", "storage", "tools"},
+			found:    true,
+		},
+		{
+			name:     "many commands",
+			comment:  "/sig sqlengine\n/sig storage",
+			sigNames: []string{"sqlengine", "storage"},
+			found:    true,
+		},
+		{
+			name:     "crlf",
+			comment:  "hello\r\n/sig storage\r\n/sig tools\r\n",
+			sigNames: []string{"storage", "tools"},
+			found:    true,
+		},
+		{
+			name:    "fenced code",
+			comment: "```\n/sig storage\n```",
+		},
+		{
+			name:    "fenced code with tildes and language",
+			comment: "~~~bash\n/sig storage\n~~~",
+		},
+		{
+			name:     "after fenced code",
+			comment:  "```\n/sig storage\n```\n/sig tools",
+			sigNames: []string{"tools"},
+			found:    true,
+		},
+		{
+			name:    "longer fence",
+			comment: "````\n/sig a\n```\n/sig b\n````",
+		},
+		{
+			name:    "fence of the other character",
+			comment: "```\n/sig a\n~~~\n/sig b\n```",
+		},
+		{
+			name:     "after longer fence",
+			comment:  "````\n/sig a\n`````\n/sig b",
+			sigNames: []string{"b"},
+			found:    true,
+		},
+		{
+			name:    "indented code",
+			comment: "example:\n\n    /sig storage",
+		},
+		{
+			name:    "indented code by tab",
+			comment: "example:\n\n\t/sig storage",
+		},
+		{
+			name:    "quote",
+			comment: "> /sig storage",
+		},
+		{
+			name:     "reply to a quote",
+			comment:  "> /sig storage\n\n/sig tools",
+			sigNames: []string{"tools"},
+			found:    true,
+		},
+		{
+			name:      "invalid name",
+			comment:   "/sig storage $(rm)",
+			sigNames:  []string{"storage"},
+			malformed: 1,
+			found:     true,
+		},
+		{
+			name:    "other command",
+			comment: "/lgtm",
+		},
+		{
+			name:    "command with a longer name",
+			comment: "/sigs storage",
+		},
+		{
+			name:     "upper case",
+			comment:  "/SIG storage",
+			sigNames: []string{"storage"},
+			found:    true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sigNames, malformed, found := parseSigCommand(c.comment, cmdSig)
+
+			if !reflect.DeepEqual(sigNames, c.sigNames) {
+				t.Errorf("sig names: got %v, want %v", sigNames, c.sigNames)
+			}
+
+			if len(malformed) != c.malformed {
+				t.Errorf("malformed: got %v, want %d", malformed, c.malformed)
+			}
+
+			if found != c.found {
+				t.Errorf("found: got %v, want %v", found, c.found)
+			}
+		})
+	}
+}
+
+func TestParseSigCommandNames(t *testing.T) {
+	comment := "/remove-sig storage\n/unsig tools\n/sig sqlengine"
+
+	sigNames, _, found := parseSigCommand(comment, cmdRemoveSig, cmdUnsig)
+	if want := []string{"storage", "tools"}; !found || !reflect.DeepEqual(sigNames, want) {
+		t.Errorf("got %v, %v, want %v", sigNames, found, want)
+	}
+}
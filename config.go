@@ -1,11 +1,24 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
 	"github.com/opensourceways/community-robot-lib/config"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 type configuration struct {
 	ConfigItems []botConfig `json:"config_items,omitempty"`
+
+	// Digest is the periodic digest of the issues and pull requests of each sig
+	Digest digestConfig `json:"digest,omitempty"`
+
+	// LabelSync is how to sync the sig labels to the repositories of sigs
+	LabelSync labelSyncConfig `json:"label_sync,omitempty"`
 }
 
 func (c *configuration) configFor(org, repo string) *botConfig {
@@ -26,6 +39,60 @@ func (c *configuration) configFor(org, repo string) *botConfig {
 	return nil
 }
 
+// relationSources returns the relation sources of the config items without duplicates.
+func (c *configuration) relationSources() []relationSource {
+	var r []relationSource
+	for i := range c.ConfigItems {
+		if src := c.ConfigItems[i].RelationSource; !isOneOfSources(src, r) {
+			r = append(r, src)
+		}
+	}
+
+	return r
+}
+
+// orgsOf returns the organizations of the config items which use the relation source.
+func (c *configuration) orgsOf(src *relationSource) []string {
+	orgs := sets.NewString()
+	for i := range c.ConfigItems {
+		if item := &c.ConfigItems[i]; item.RelationSource == *src {
+			for _, v := range item.Repos {
+				orgs.Insert(strings.Split(v, "/")[0])
+			}
+		}
+	}
+
+	return orgs.List()
+}
+
+// usesSource reports whether the repository is configured with the relation source.
+func (c *configuration) usesSource(org, repo string, src *relationSource) bool {
+	bc := c.configFor(org, repo)
+
+	return bc != nil && bc.RelationSource == *src
+}
+
+// isRelationChanged reports whether the push event changed any relationship.
+func (c *configuration) isRelationChanged(org, repo, ref string) bool {
+	for _, src := range c.relationSources() {
+		if org == src.Org && repo == src.Repo && ref == "refs/heads/"+src.Ref {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isOneOfSources(src relationSource, v []relationSource) bool {
+	for i := range v {
+		if v[i] == src {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *configuration) Validate() error {
 	if c == nil {
 		return nil
@@ -38,7 +105,11 @@ func (c *configuration) Validate() error {
 		}
 	}
 
-	return nil
+	if err := c.Digest.validate(); err != nil {
+		return err
+	}
+
+	return c.LabelSync.validate()
 }
 
 func (c *configuration) SetDefault() {
@@ -50,6 +121,9 @@ func (c *configuration) SetDefault() {
 	for i := range Items {
 		Items[i].setDefault()
 	}
+
+	c.Digest.setDefault()
+	c.LabelSync.setDefault()
 }
 
 type botConfig struct {
@@ -57,12 +131,265 @@ type botConfig struct {
 
 	// CustomizeMembers means use a new maintainers' and committers' relationship of repositories
 	CustomizeMembers bool `json:"customize_members,omitempty"`
+
+	// RelationSource is where the relationship of sigs and the OWNERS of sigs are stored
+	RelationSource relationSource `json:"relation_source,omitempty"`
+
+	// Templates are the templates of the messages posted by the robot
+	Templates templateConfig `json:"templates,omitempty"`
+
+	// ManualSig is the rule of issues which need a sig chosen by the /sig command
+	// instead of being labeled by the sig of repository
+	ManualSig manualSigConfig `json:"manual_sig,omitempty"`
+
+	// LabelIssueBySigCommand means the robot adds the sig labels chosen by the /sig command to the issue,
+	// and removes the other sig labels it added before, then posts the guide.
+	// Otherwise it only posts the guide and leaves labeling to other robots.
+	LabelIssueBySigCommand bool `json:"label_issue_by_sig_command,omitempty"`
+
+	// SigCommandPermission is who can run the /sig, /remove-sig and /unsig commands
+	SigCommandPermission commandPermission `json:"sig_command_permission,omitempty"`
+
+	// MentionLimit is the max number of members mentioned in each of owners, maintainers and committers,
+	// a link to the OWNERS file is shown if any of them is capped. 0 means no limit.
+	MentionLimit int `json:"mention_limit,omitempty"`
+
+	// FirstContact is how to pick the first contacts from the owners,
+	// the other owners are listed without being mentioned
+	FirstContact firstContactConfig `json:"first_contact,omitempty"`
+
+	// Assign is whether to assign the issues and pull requests to their first contacts and reviewers
+	Assign assignConfig `json:"assign,omitempty"`
+
+	// Escalation is when to ping the members again if the issues and pull requests get no response
+	Escalation escalationConfig `json:"escalation,omitempty"`
 }
 
 func (c *botConfig) setDefault() {
+	c.RelationSource.setDefault()
+	c.Templates.setDefault(&c.RelationSource)
+	c.ManualSig.setDefault()
+	c.SigCommandPermission.setDefault()
+	c.FirstContact.setDefault()
 }
 
 func (c *botConfig) validate() error {
+	if err := c.RelationSource.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Templates.validate(); err != nil {
+		return err
+	}
+
+	if err := c.ManualSig.validate(); err != nil {
+		return err
+	}
+
+	if err := c.SigCommandPermission.validate(); err != nil {
+		return err
+	}
+
+	if c.MentionLimit < 0 {
+		return errors.New("mention_limit must not be negative")
+	}
+
+	if err := c.FirstContact.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Assign.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Escalation.validate(); err != nil {
+		return err
+	}
 
 	return c.RepoFilter.Validate()
 }
+
+type relationSource struct {
+	// Org is the organization of the repository which stores the relationship, default is opengauss
+	Org string `json:"org,omitempty"`
+
+	// Repo is the repository which stores the relationship, default is tc
+	Repo string `json:"repo,omitempty"`
+
+	// Path is the path of the relationship file, default is gauss_relationship.yaml
+	Path string `json:"path,omitempty"`
+
+	// OwnersPath is the pattern of the path of sig's OWNERS file, %s will be replaced by the sig name.
+	// Default is sigs/%s/OWNERS
+	OwnersPath string `json:"owners_path,omitempty"`
+
+	// Ref is the branch of the repository, default is master
+	Ref string `json:"ref,omitempty"`
+}
+
+func (s *relationSource) setDefault() {
+	if s.Org == "" {
+		s.Org = "opengauss"
+	}
+
+	if s.Repo == "" {
+		s.Repo = "tc"
+	}
+
+	if s.Path == "" {
+		s.Path = "gauss_relationship.yaml"
+	}
+
+	if s.OwnersPath == "" {
+		s.OwnersPath = "sigs/%s/OWNERS"
+	}
+
+	if s.Ref == "" {
+		s.Ref = "master"
+	}
+}
+
+func (s *relationSource) validate() error {
+	if s.Org == "" || s.Repo == "" {
+		return errors.New("missing org or repo of relation_source")
+	}
+
+	if s.Path == "" {
+		return errors.New("missing path of relation_source")
+	}
+
+	if strings.Count(s.OwnersPath, "%s") != 1 {
+		return errors.New("owners_path of relation_source must contain one %s for the sig name")
+	}
+
+	if s.Ref == "" {
+		return errors.New("missing ref of relation_source")
+	}
+
+	return nil
+}
+
+func (s *relationSource) ownersPath(sigName string) string {
+	return fmt.Sprintf(s.OwnersPath, sigName)
+}
+
+func (s *relationSource) ownersURL(sigName string) string {
+	return fmt.Sprintf("https://gitee.com/%s/%s/blob/%s/%s", s.Org, s.Repo, s.Ref, s.ownersPath(sigName))
+}
+
+// cacheKey returns the key of a file in the relation source, all keys of the
+// same repository share the prefix returned by cachePrefix.
+func (s *relationSource) cacheKey(kind, path string) string {
+	return fmt.Sprintf("%s%s:%s:%s", cachePrefix(s.Org, s.Repo), s.Ref, kind, path)
+}
+
+func cachePrefix(org, repo string) string {
+	return org + "/" + repo + "@"
+}
+
+type templateConfig struct {
+	// Language is the language of messages, it can be en, zh or auto.
+	// auto means choosing the language by the content of issue or pull request. Default is en
+	Language string `json:"language,omitempty"`
+
+	// Path is the path of the templates file in the relation source.
+	// The file can define the default templates and the templates of each sig.
+	Path string `json:"path,omitempty"`
+
+	// Items are the templates of each language for the repositories, they override the templates file.
+	Items map[string]templateSet `json:"items,omitempty"`
+
+	// SigsURL is the link to the page listing all the sigs, it is shown in the sig notice.
+	// Default is the page of opengauss.org if the relation source is opengauss/tc
+	SigsURL string `json:"sigs_url,omitempty"`
+}
+
+// defaultSigsURL is the page listing all the sigs of openGauss.
+const defaultSigsURL = "https://opengauss.org/zh/member.html#sig"
+
+func (t *templateConfig) setDefault(src *relationSource) {
+	if t.Language == "" {
+		t.Language = langEN
+	}
+
+	if t.SigsURL == "" && src.Org == "opengauss" && src.Repo == "tc" {
+		t.SigsURL = defaultSigsURL
+	}
+}
+
+func (t *templateConfig) validate() error {
+	switch t.Language {
+	case langEN, langZH, langAuto:
+	default:
+		return fmt.Errorf("unsupported language: %s", t.Language)
+	}
+
+	for lang, v := range t.Items {
+		for _, kind := range []string{tmplIssueGuide, tmplPRGuide, tmplSigNotice, tmplReminder} {
+			if s := v.get(kind); s != "" {
+				if _, err := template.New(kind).Funcs(templateFuncs).Parse(s); err != nil {
+					return fmt.Errorf("invalid template %s of %s: %v", kind, lang, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultManualSigRepos are the repositories whose issues need a sig chosen manually if manual_sig.repos is not set.
+var defaultManualSigRepos = []string{"opengauss/openGauss-server"}
+
+type manualSigConfig struct {
+	// Repos are the repositories whose issues need a sig chosen manually, in the form of org/repo.
+	// Default is opengauss/openGauss-server, set it to [] for none
+	Repos []string `json:"repos"`
+
+	// MultiSigRepos means the issues need a sig chosen manually if the repository belongs to more than one sig
+	MultiSigRepos bool `json:"multi_sig_repos,omitempty"`
+
+	// FallbackTimeout is the minutes to wait for the /sig command, after which the issue
+	// is labeled by the sig of repository if it still has no sig label. 0 means never.
+	FallbackTimeout int `json:"fallback_timeout,omitempty"`
+}
+
+func (m *manualSigConfig) setDefault() {
+	if m.Repos == nil {
+		m.Repos = append([]string{}, defaultManualSigRepos...)
+	}
+}
+
+func (m *manualSigConfig) validate() error {
+	if m.FallbackTimeout < 0 {
+		return errors.New("fallback_timeout of manual_sig must not be negative")
+	}
+
+	return nil
+}
+
+// needManual reports whether the issues of the repository need a sig chosen manually.
+func (m *manualSigConfig) needManual(org, repo string, sigs *SigYaml) bool {
+	p := org + "/" + repo
+	for _, r := range m.Repos {
+		if r == p {
+			return true
+		}
+	}
+
+	if !m.MultiSigRepos {
+		return false
+	}
+
+	n := 0
+	for i := range sigs.Sigs {
+		if sigs.Sigs[i].hasRepo(repo) {
+			n++
+		}
+	}
+
+	return n > 1
+}
+
+func (m *manualSigConfig) fallbackTimeout() time.Duration {
+	return time.Duration(m.FallbackTimeout) * time.Minute
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/config"
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	digestToIssue = "issue"
+	digestToFile  = "file"
+
+	// digestCheckInterval is how often to check whether the digest is due
+	digestCheckInterval = time.Hour
+
+	dateLayout = "2006-01-02"
+)
+
+const digestTemplate = `## Digest of SIG [{{.Name}}]({{.Link}}) from {{date .From}} to {{date .To}}
+{{if .New}}
+### New issues and pull requests
+{{range .New}}- {{link .}}
+{{end}}{{end}}{{if .Unlabeled}}
+### Issues and pull requests without a SIG label
+{{range .Unlabeled}}- {{link .}} opened at {{date .OpenedAt}}
+{{end}}{{end}}{{if .Unanswered}}
+### Waiting for the response of owners
+{{range .Unanswered}}- {{link .}} since {{date .GuidedAt}}
+{{end}}{{end}}`
+
+var digestTmpl = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format(dateLayout)
+	},
+	"link": func(w *watchedItem) string {
+		if w.IsPR {
+			return fmt.Sprintf("[%s/%s!%s](https://gitee.com/%s/%s/pulls/%s)", w.Org, w.Repo, w.Number, w.Org, w.Repo, w.Number)
+		}
+
+		return fmt.Sprintf("[%s/%s#%s](https://gitee.com/%s/%s/issues/%s)", w.Org, w.Repo, w.Number, w.Org, w.Repo, w.Number)
+	},
+}).Parse(digestTemplate))
+
+type digestConfig struct {
+	// Days is the period of the digest of each sig, 0 means no digest
+	Days int `json:"days,omitempty"`
+
+	// Destination is where the digest is published, which can be issue or file. Default is issue
+	Destination string `json:"destination,omitempty"`
+
+	// Org is the organization of the repository in which the issue of digest is created, default is
+	// the organization of the config items of the relation source, which has the repository
+	Org string `json:"org,omitempty"`
+
+	// Repo is the repository in which the issue of digest is created, default is the first repository of the sig
+	Repo string `json:"repo,omitempty"`
+
+	// Path is the pattern of the path of the digest file in the relation source of the sigs, the two %s
+	// will be replaced by the sig name and the date. Default is digests/%s/%s.md
+	Path string `json:"path,omitempty"`
+}
+
+func (c *digestConfig) setDefault() {
+	if c.Destination == "" {
+		c.Destination = digestToIssue
+	}
+
+	if c.Path == "" {
+		c.Path = "digests/%s/%s.md"
+	}
+}
+
+func (c *digestConfig) validate() error {
+	if c.Days < 0 {
+		return errors.New("days of digest must not be negative")
+	}
+
+	switch c.Destination {
+	case digestToIssue, digestToFile:
+	default:
+		return fmt.Errorf("unknown destination of digest: %s", c.Destination)
+	}
+
+	if strings.Count(c.Path, "%s") != 2 {
+		return errors.New("path of digest must contain two %s for the sig name and the date")
+	}
+
+	return nil
+}
+
+func (c *digestConfig) period() time.Duration {
+	return time.Duration(c.Days) * 24 * time.Hour
+}
+
+// sigDigest is the data to render the digest of a sig.
+type sigDigest struct {
+	Name string
+	Link string
+	From time.Time
+	To   time.Time
+
+	// New are the items watched during the period, Unlabeled are the items without a sig label
+	// in the repositories of the sig, and Unanswered are the items waiting for the response of owners.
+	New        []*watchedItem
+	Unlabeled  []*watchedItem
+	Unanswered []*watchedItem
+}
+
+func (d *sigDigest) empty() bool {
+	return len(d.New) == 0 && len(d.Unlabeled) == 0 && len(d.Unanswered) == 0
+}
+
+// runDigest publishes the digest of each sig when it is due.
+func (bot *robot) runDigest(agent *config.ConfigAgent) {
+	t := time.NewTicker(digestCheckInterval)
+	defer t.Stop()
+
+	for range t.C {
+		_, cfg := agent.GetConfig()
+		if c, ok := cfg.(*configuration); ok && c != nil {
+			if err := bot.digest(c); err != nil {
+				logrus.WithError(err).Error("digest")
+			}
+		}
+	}
+}
+
+// digest publishes the digest of each sig in the relation sources of the config items.
+func (bot *robot) digest(cfg *configuration) error {
+	if cfg.Digest.Days == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, src := range cfg.relationSources() {
+		src := src
+		if err := bot.digestSigs(cfg, &src); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// digestSigs publishes the digest of each sig of the relation source whose last one is published a period
+// ago, which lists the items of the repositories configured with the relation source. The time of the last
+// digest is only moved forward if the digest is published, so that a failed one is retried next time.
+func (bot *robot) digestSigs(cfg *configuration, src *relationSource) error {
+	c := &cfg.Digest
+
+	sigs, err := bot.decodeSigsContent(src)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	from := make(map[string]time.Time)
+	bot.store.view(func(d *stateData) {
+		for i := range sigs.Sigs {
+			name := sigs.Sigs[i].Name
+			if last := d.LastDigests[src.cacheKey("digest", name)]; last.IsZero() {
+				from[name] = now.Add(-c.period())
+			} else if now.Sub(last) >= c.period() {
+				from[name] = last
+			}
+		}
+	})
+
+	if len(from) == 0 {
+		return nil
+	}
+
+	var errs []error
+	isOfSource := func(w *watchedItem) bool {
+		return cfg.usesSource(w.Org, w.Repo, src)
+	}
+
+	for _, d := range bot.genDigests(sigs, from, now, isOfSource) {
+		if !d.empty() {
+			if err := bot.publishDigest(cfg, src, sigs, &d); err != nil {
+				errs = append(errs, fmt.Errorf("publish the digest of sig %s: %v", d.Name, err))
+
+				continue
+			}
+		}
+
+		key := src.cacheKey("digest", d.Name)
+		err := bot.store.update(func(s *stateData) {
+			if s.LastDigests == nil {
+				s.LastDigests = make(map[string]time.Time)
+			}
+
+			s.LastDigests[key] = now
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// genDigests collects the watched items accepted by filter for each sig in from, which is the start of
+// the period of the sig. The watched items are refreshed at first, since the escalation may be disabled
+// and the items may be labeled by others.
+func (bot *robot) genDigests(
+	sigs *SigYaml, from map[string]time.Time, to time.Time, filter func(*watchedItem) bool,
+) []sigDigest {
+	items := make(map[string]*watchedItem)
+	bot.store.view(func(d *stateData) {
+		for k, v := range d.Watched {
+			if filter(v) {
+				w := *v
+				items[k] = &w
+			}
+		}
+	})
+
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var watched []*watchedItem
+	for _, k := range keys {
+		w := items[k]
+		if len(w.Labels) == 0 {
+			if unlabeled, err := bot.refreshUnlabeledItem(k, w); err != nil {
+				logrus.WithError(err).WithField("item", k).Error("refresh unlabeled item for digest")
+			} else if !unlabeled {
+				continue
+			}
+		} else if !w.Responded {
+			if _, waiting, err := bot.refreshItem(k, w); err != nil {
+				logrus.WithError(err).WithField("item", k).Error("refresh item for digest")
+			} else if !waiting && !w.Responded {
+				// the item is closed.
+				continue
+			}
+		}
+
+		watched = append(watched, w)
+	}
+
+	r := make([]sigDigest, 0, len(from))
+	for i := range sigs.Sigs {
+		s := &sigs.Sigs[i]
+		start, ok := from[s.Name]
+		if !ok {
+			continue
+		}
+
+		d := sigDigest{Name: s.Name, Link: s.SigLink, From: start, To: to}
+
+		for _, w := range watched {
+			if len(w.Labels) == 0 {
+				if s.hasRepo(w.Repo) {
+					d.Unlabeled = append(d.Unlabeled, w)
+				}
+
+				continue
+			}
+
+			if !isOneOf(s.SigLabel, w.Labels) {
+				continue
+			}
+
+			if w.OpenedAt.After(start) {
+				d.New = append(d.New, w)
+			}
+
+			if !w.Responded {
+				d.Unanswered = append(d.Unanswered, w)
+			}
+		}
+
+		r = append(r, d)
+	}
+
+	return r
+}
+
+func (bot *robot) publishDigest(cfg *configuration, src *relationSource, sigs *SigYaml, d *sigDigest) error {
+	c := &cfg.Digest
+
+	buf := new(bytes.Buffer)
+	if err := digestTmpl.Execute(buf, d); err != nil {
+		return err
+	}
+
+	date := d.To.Format(dateLayout)
+
+	if c.Destination == digestToFile {
+		path := fmt.Sprintf(c.Path, d.Name, date)
+		content := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+		_, err := bot.cli.CreateFile(
+			src.Org, src.Repo, src.Ref, path, content, fmt.Sprintf("add the digest of sig %s", d.Name),
+		)
+
+		return err
+	}
+
+	repo := c.Repo
+	if repo == "" {
+		repo = firstRepoOfSig(sigs, d.Name)
+	}
+
+	if repo == "" {
+		return fmt.Errorf("no repository to publish the digest of sig %s", d.Name)
+	}
+
+	_, err := bot.cli.CreateIssue(
+		digestOrg(cfg, src, repo), repo, fmt.Sprintf("Digest of SIG %s %s", d.Name, date), buf.String(),
+	)
+
+	return err
+}
+
+// digestOrg returns the organization of the repository in which the issue of digest is created.
+// Unless digest.org is set, it is the organization of the config items of the relation source
+// which has the repository, or the organization of the relation source if none has it.
+func digestOrg(cfg *configuration, src *relationSource, repo string) string {
+	if cfg.Digest.Org != "" {
+		return cfg.Digest.Org
+	}
+
+	for _, org := range cfg.orgsOf(src) {
+		if cfg.usesSource(org, repo, src) {
+			return org
+		}
+	}
+
+	return src.Org
+}
+
+func firstRepoOfSig(sigs *SigYaml, name string) string {
+	for i := range sigs.Sigs {
+		if s := &sigs.Sigs[i]; s.Name == name {
+			for _, r := range s.Repos {
+				for _, v := range r.Repo {
+					if v != "" {
+						return v
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
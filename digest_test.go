@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+)
+
+// digestClient records the organization of each issue of digest created.
+type digestClient struct {
+	iClient
+
+	orgs map[string]string
+}
+
+func (c *digestClient) CreateIssue(org, repo, title, body string) (sdk.Issue, error) {
+	c.orgs[repo] = org
+
+	return sdk.Issue{}, nil
+}
+
+func TestPublishDigestInTheOrgOfRelationSource(t *testing.T) {
+	opengauss := relationSource{}
+	opengauss.setDefault()
+
+	sister := relationSource{Org: "sister", Repo: "community"}
+	sister.setDefault()
+
+	cfg := &configuration{ConfigItems: []botConfig{
+		{RelationSource: opengauss},
+		{RelationSource: sister},
+	}}
+	cfg.ConfigItems[0].Repos = []string{"opengauss/server"}
+	cfg.ConfigItems[1].Repos = []string{"sister/kernel"}
+
+	cases := []struct {
+		name string
+		org  string
+		src  *relationSource
+		repo string
+		want string
+	}{
+		{name: "opengauss", src: &opengauss, repo: "server", want: "opengauss"},
+		{name: "sister community", src: &sister, repo: "kernel", want: "sister"},
+		{name: "repository not configured", src: &sister, repo: "docs", want: "sister"},
+		{name: "digest.org is set", org: "digests", src: &sister, repo: "kernel", want: "digests"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cli := &digestClient{orgs: map[string]string{}}
+			bot := &robot{cli: cli}
+
+			cfg.Digest = digestConfig{Org: c.org}
+			cfg.Digest.setDefault()
+
+			sigs := &SigYaml{Sigs: []Sig{{Name: "s", Repos: []RepoMember{{Repo: []string{c.repo}}}}}}
+			if err := bot.publishDigest(cfg, c.src, sigs, &sigDigest{Name: "s"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if v := cli.orgs[c.repo]; v != c.want {
+				t.Errorf("org: got %s, want %s", v, c.want)
+			}
+		})
+	}
+}
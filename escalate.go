@@ -0,0 +1,440 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/config"
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// the steps of the escalation, each of which pings the members who are not pinged before.
+const (
+	stepOwners = iota + 1
+	stepMaintainers
+	stepCommitters
+	stepDefaultOwners
+)
+
+type escalationConfig struct {
+	// Days is the days to wait for the response of the members mentioned in the guide.
+	// After each period without response, the robot pings the first owners again, then the maintainers,
+	// the committers and the default owners in turn. 0 means no escalation.
+	Days int `json:"days,omitempty"`
+}
+
+func (c *escalationConfig) validate() error {
+	if c.Days < 0 {
+		return errors.New("days of escalation must not be negative")
+	}
+
+	return nil
+}
+
+func (c *escalationConfig) period() time.Duration {
+	return time.Duration(c.Days) * 24 * time.Hour
+}
+
+// watchedItem is an open issue or pull request, which waits for a sig label or the response of
+// the members mentioned in the guide.
+type watchedItem struct {
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Number string `json:"number"`
+	IsPR   bool   `json:"is_pr,omitempty"`
+
+	// Labels are the labels of the sigs in the guide, it is empty if the item has no sig yet
+	Labels []string `json:"labels,omitempty"`
+
+	Owners      []string `json:"owners,omitempty"`
+	Maintainers []string `json:"maintainers,omitempty"`
+	Committers  []string `json:"committers,omitempty"`
+
+	// Pinged are the members pinged by the escalation, who are never pinged twice
+	Pinged []string `json:"pinged,omitempty"`
+
+	// Step is the last step of the escalation done
+	Step int `json:"step,omitempty"`
+
+	// Responded means any member mentioned in the guide or pinged has commented
+	Responded bool `json:"responded,omitempty"`
+
+	// FallbackAt is when to label the issue by the sig of repository if nobody has chosen a sig for it,
+	// it is zero if there is no pending fallback
+	FallbackAt time.Time `json:"fallback_at"`
+
+	// OpenedAt is when the item is watched at first, GuidedAt is when the guide is posted,
+	// and Since is when the guide or the last step is posted
+	OpenedAt time.Time `json:"opened_at"`
+	GuidedAt time.Time `json:"guided_at"`
+	Since    time.Time `json:"since"`
+}
+
+// watchItem starts watching the item after its guide is posted, or without the guide if data is nil
+// which means the item has no sig yet. The escalation restarts if the guide is posted again for other sigs.
+// The pending fallback of manual_sig is only kept if there is no guide, since the sigs of the guide
+// may be chosen by /sig without labeling the issue. The first contacts picked for the guide are recorded too.
+func (bot *robot) watchItem(org, repo, number string, isPR bool, data *messageData) error {
+	now := time.Now()
+	w := &watchedItem{
+		Org:      org,
+		Repo:     repo,
+		Number:   number,
+		IsPR:     isPR,
+		OpenedAt: now,
+		GuidedAt: now,
+		Since:    now,
+	}
+
+	if data != nil {
+		for i := range data.Sigs {
+			w.Labels = append(w.Labels, data.Sigs[i].Label)
+		}
+
+		w.Owners = data.Owners
+		w.Maintainers = data.Maintainers
+		w.Committers = data.Committers
+	}
+
+	key := issueKey(org, repo, number)
+	if isPR {
+		key = prKey(org, repo, int32(atoi(number)))
+	}
+
+	return bot.store.update(func(d *stateData) {
+		if d.Watched == nil {
+			d.Watched = make(map[string]*watchedItem)
+		}
+
+		if v := d.Watched[key]; v != nil {
+			w.OpenedAt = v.OpenedAt
+
+			if data == nil {
+				w.FallbackAt = v.FallbackAt
+			}
+		}
+
+		d.Watched[key] = w
+
+		if data != nil && data.item != "" {
+			d.recordFirstContacts(data.item, data.picks)
+		}
+	})
+}
+
+// fallbackInterval is how often the fallback of manual_sig is checked if the escalation is disabled.
+const fallbackInterval = time.Minute
+
+// runEscalation checks the watched items every interval, it does the fallback of manual_sig too.
+// If interval is not positive, only the fallback is checked every fallbackInterval.
+func (bot *robot) runEscalation(agent *config.ConfigAgent, interval time.Duration) {
+	escalation := interval > 0
+	if !escalation {
+		interval = fallbackInterval
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for range t.C {
+		_, cfg := agent.GetConfig()
+		bot.escalate(cfg, escalation)
+	}
+}
+
+// escalate does the fallback of the watched items which are due, and the escalation of the others
+// if escalation is true.
+func (bot *robot) escalate(cfg config.Config, escalation bool) {
+	items := make(map[string]watchedItem)
+	bot.store.view(func(d *stateData) {
+		for k, v := range d.Watched {
+			items[k] = *v
+		}
+	})
+
+	for k, item := range items {
+		log := logrus.WithField("item", k)
+
+		bc, err := bot.getConfig(cfg, item.Org, item.Repo)
+		if err != nil {
+			log.WithError(err).Error("get config")
+
+			continue
+		}
+
+		if !item.FallbackAt.IsZero() && !time.Now().Before(item.FallbackAt) {
+			if err := bot.fallbackItem(bc, k, item); err != nil {
+				log.WithError(err).Error("fallback to the sig of repository")
+			}
+
+			continue
+		}
+
+		if !escalation {
+			continue
+		}
+
+		if err := bot.escalateItem(bc, k, item); err != nil {
+			log.WithError(err).Error("escalate")
+		}
+	}
+}
+
+// fallbackItem labels the issue by the sig of repository when its fallback is due, see fallbackIssueSig.
+func (bot *robot) fallbackItem(bc *botConfig, key string, item watchedItem) error {
+	issue, err := bot.cli.GetIssue(item.Org, item.Repo, item.Number)
+	if err != nil {
+		return err
+	}
+
+	if issue.State == issueStateClosed || issue.State == issueStateRejected {
+		return bot.releaseItem(key)
+	}
+
+	err = bot.fallbackIssueSig(bc, item.Org, item.Repo, item.Number, userLogin(issue.User), issue.Body)
+	if err != nil {
+		return err
+	}
+
+	return bot.store.update(func(d *stateData) {
+		if w := d.Watched[key]; w != nil && w.FallbackAt.Equal(item.FallbackAt) {
+			w.FallbackAt = time.Time{}
+		}
+	})
+}
+
+// escalateItem does the next step of the escalation when the period is over,
+// unless any member mentioned in the guide or pinged has commented.
+func (bot *robot) escalateItem(bc *botConfig, key string, item watchedItem) error {
+	if bc.Escalation.Days == 0 || len(item.Labels) == 0 || item.Responded || item.Step >= stepDefaultOwners {
+		return nil
+	}
+
+	if time.Since(item.Since) < bc.Escalation.period() {
+		return nil
+	}
+
+	status, waiting, err := bot.refreshItem(key, &item)
+	if err != nil || !waiting {
+		return err
+	}
+
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
+	if err != nil {
+		return err
+	}
+
+	step, targets := item.nextStep(sigs, status.author)
+
+	var comment string
+	if len(targets) > 0 {
+		data := &messageData{
+			Author: status.author,
+			Owners: targets,
+			Days:   int(time.Since(item.GuidedAt).Hours() / 24),
+		}
+
+		for _, l := range item.Labels {
+			for i := range sigs.Sigs {
+				if s := &sigs.Sigs[i]; s.SigLabel == l {
+					data.Sigs = append(data.Sigs, sigData{Name: s.Name, Label: s.SigLabel, Link: s.SigLink})
+				}
+			}
+		}
+
+		if comment, err = bot.genMessage(bc, tmplReminder, status.body, data); err != nil {
+			return err
+		}
+
+		// the targets may be capped by mention_limit, in which case the step is done again
+		// next time for the members not pinged yet.
+		if len(data.Owners) < len(targets) {
+			step--
+		}
+		targets = data.Owners
+	}
+
+	if comment != "" {
+		if item.IsPR {
+			err = bot.cli.CreatePRComment(item.Org, item.Repo, int32(atoi(item.Number)), comment)
+		} else {
+			err = bot.cli.CreateIssueComment(item.Org, item.Repo, item.Number, comment)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return bot.store.update(func(d *stateData) {
+		// the item may be closed or guided again meanwhile.
+		if w := d.Watched[key]; w != nil && w.GuidedAt.Equal(item.GuidedAt) {
+			w.Step = step
+			w.Pinged = append(w.Pinged, targets...)
+			w.Since = time.Now()
+		}
+	})
+}
+
+// nextStep returns the next step which has any member not pinged before, and those members.
+// It returns the last step if there is no one to ping.
+func (item *watchedItem) nextStep(sigs *SigYaml, author string) (int, []string) {
+	pinged := sets.NewString(item.Pinged...).Insert(author)
+
+	for step := item.Step + 1; step <= stepDefaultOwners; step++ {
+		var v []string
+		switch step {
+		case stepOwners:
+			v = item.Owners
+
+		case stepMaintainers:
+			v = item.Maintainers
+
+		case stepCommitters:
+			v = item.Committers
+
+		case stepDefaultOwners:
+			for _, d := range sigs.DefaultOwners {
+				v = append(v, d.GiteeID)
+			}
+		}
+
+		if r := sets.NewString(v...).Difference(pinged); len(r) > 0 {
+			return step, r.List()
+		}
+	}
+
+	return stepDefaultOwners, nil
+}
+
+// refreshItem releases the item if it is closed, and marks it responded if any member mentioned
+// in the guide or pinged has commented. It reports whether the item still waits for the response.
+func (bot *robot) refreshItem(key string, item *watchedItem) (itemStatus, bool, error) {
+	status, err := bot.getItemStatus(item, true)
+	if err != nil {
+		return status, false, err
+	}
+
+	if !status.open {
+		return status, false, bot.releaseItem(key)
+	}
+
+	members := sets.NewString(item.Owners...)
+	members.Insert(item.Maintainers...)
+	members.Insert(item.Committers...)
+	members.Insert(item.Pinged...)
+
+	for i := range status.comments {
+		c := &status.comments[i]
+		if !members.Has(c.author) {
+			continue
+		}
+
+		// a comment of which the time can't be parsed is regarded as the response too.
+		if t, err := time.Parse(time.RFC3339, c.createdAt); err != nil || t.After(item.GuidedAt) {
+			item.Responded = true
+
+			return status, false, bot.store.update(func(d *stateData) {
+				if w := d.Watched[key]; w != nil && w.GuidedAt.Equal(item.GuidedAt) {
+					w.Responded = true
+				}
+			})
+		}
+	}
+
+	return status, true, nil
+}
+
+// refreshUnlabeledItem releases the item without a sig label if it is closed, and stops watching it
+// if it is labeled by others meanwhile. It reports whether the item still has no sig label.
+func (bot *robot) refreshUnlabeledItem(key string, item *watchedItem) (bool, error) {
+	status, err := bot.getItemStatus(item, false)
+	if err != nil {
+		return false, err
+	}
+
+	if !status.open {
+		return false, bot.releaseItem(key)
+	}
+
+	if len(status.sigLabels) == 0 {
+		return true, nil
+	}
+
+	return false, bot.store.update(func(d *stateData) {
+		// the robot may have posted the guide meanwhile.
+		if w := d.Watched[key]; w != nil && len(w.Labels) == 0 {
+			delete(d.Watched, key)
+		}
+	})
+}
+
+type itemStatus struct {
+	open      bool
+	author    string
+	body      string
+	sigLabels []string
+	comments  []guideComment
+}
+
+// getItemStatus gets the status of the item, the comments are listed only if withComments is true.
+func (bot *robot) getItemStatus(item *watchedItem, withComments bool) (itemStatus, error) {
+	var r itemStatus
+
+	if item.IsPR {
+		number := int32(atoi(item.Number))
+
+		pr, err := bot.cli.GetGiteePullRequest(item.Org, item.Repo, number)
+		if err != nil {
+			return r, err
+		}
+
+		r.open = pr.State != prStateClosed && pr.State != prStateMerged
+		r.author = userLogin(pr.User)
+		r.body = pr.Body
+		r.sigLabels = sigLabelsIn(pr.Labels)
+
+		if r.open && withComments {
+			r.comments, err = bot.listPRComments(item.Org, item.Repo, number)
+		}
+
+		return r, err
+	}
+
+	issue, err := bot.cli.GetIssue(item.Org, item.Repo, item.Number)
+	if err != nil {
+		return r, err
+	}
+
+	r.open = issue.State != issueStateClosed && issue.State != issueStateRejected
+	r.author = userLogin(issue.User)
+	r.body = issue.Body
+	r.sigLabels = sigLabelsIn(issue.Labels)
+
+	if r.open && withComments {
+		r.comments, err = bot.listIssueComments(item.Org, item.Repo, item.Number)
+	}
+
+	return r, err
+}
+
+func sigLabelsIn(labels []sdk.Label) []string {
+	var r []string
+	for _, l := range labels {
+		if strings.HasPrefix(l.Name, "sig/") {
+			r = append(r, l.Name)
+		}
+	}
+
+	return r
+}
+
+func atoi(s string) int {
+	v, _ := strconv.Atoi(s)
+
+	return v
+}
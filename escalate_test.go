@@ -0,0 +1,133 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextStep(t *testing.T) {
+	sigs := &SigYaml{DefaultOwners: []Member{{GiteeID: "d1"}, {GiteeID: "d2"}}}
+
+	cases := []struct {
+		name   string
+		item   watchedItem
+		author string
+
+		step    int
+		targets []string
+	}{
+		{
+			name:    "owners at first",
+			item:    watchedItem{Owners: []string{"o2", "o1"}, Maintainers: []string{"m1"}},
+			step:    stepOwners,
+			targets: []string{"o1", "o2"},
+		},
+		{
+			name:    "maintainers after owners",
+			item:    watchedItem{Step: stepOwners, Owners: []string{"o1"}, Maintainers: []string{"m1"}, Pinged: []string{"o1"}},
+			step:    stepMaintainers,
+			targets: []string{"m1"},
+		},
+		{
+			name: "a step done is not repeated for the members not pinged",
+			item: watchedItem{
+				Step:        stepOwners,
+				Owners:      []string{"o1", "o2", "o3"},
+				Maintainers: []string{"m1"},
+				Pinged:      []string{"o1"},
+			},
+			step:    stepMaintainers,
+			targets: []string{"m1"},
+		},
+		{
+			name: "the step done again after being capped",
+			item: watchedItem{
+				Step:        stepOwners - 1,
+				Owners:      []string{"o1", "o2", "o3"},
+				Maintainers: []string{"m1"},
+				Pinged:      []string{"o1"},
+			},
+			step:    stepOwners,
+			targets: []string{"o2", "o3"},
+		},
+		{
+			name: "the step without anyone not pinged is skipped",
+			item: watchedItem{
+				Step:        stepOwners,
+				Owners:      []string{"o1"},
+				Maintainers: []string{"o1"},
+				Committers:  []string{"c1", "o1"},
+				Pinged:      []string{"o1"},
+			},
+			step:    stepCommitters,
+			targets: []string{"c1"},
+		},
+		{
+			name:    "the author is never pinged",
+			item:    watchedItem{Owners: []string{"a"}, Maintainers: []string{"a", "m1"}},
+			author:  "a",
+			step:    stepMaintainers,
+			targets: []string{"m1"},
+		},
+		{
+			name:    "default owners at last",
+			item:    watchedItem{Step: stepCommitters, Owners: []string{"o1"}},
+			step:    stepDefaultOwners,
+			targets: []string{"d1", "d2"},
+		},
+		{
+			name: "no one to ping",
+			item: watchedItem{Step: stepCommitters, Pinged: []string{"d1", "d2"}},
+			step: stepDefaultOwners,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			step, targets := c.item.nextStep(sigs, c.author)
+
+			if step != c.step {
+				t.Errorf("step: got %d, want %d", step, c.step)
+			}
+
+			if !reflect.DeepEqual(targets, c.targets) {
+				t.Errorf("targets: got %v, want %v", targets, c.targets)
+			}
+		})
+	}
+}
+
+func TestWatchItemKeepsFallbackOnlyWithoutGuide(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     *messageData
+		fallback bool
+	}{
+		{
+			name:     "no guide",
+			fallback: true,
+		},
+		{
+			name: "the guide of the sigs chosen by /sig",
+			data: &messageData{Sigs: []sigData{{Name: "storage", Label: "sig/storage"}}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key := issueKey("o", "r", "I1")
+			bot := &robot{store: &stateStore{data: stateData{
+				Watched: map[string]*watchedItem{key: {FallbackAt: time.Now().Add(time.Hour)}},
+			}}}
+
+			if err := bot.watchItem("o", "r", "I1", false, c.data); err != nil {
+				t.Fatal(err)
+			}
+
+			if v := !bot.store.data.Watched[key].FallbackAt.IsZero(); v != c.fallback {
+				t.Errorf("fallback: got %v, want %v", v, c.fallback)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ go 1.16
 require (
 	github.com/opensourceways/community-robot-lib v0.0.0-20220118064921-28924d0a1246
 	github.com/opensourceways/go-gitee v0.0.0-20220120022149-6d34985edf4f
+	github.com/prometheus/client_golang v1.12.1
 	github.com/sirupsen/logrus v1.8.1
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/apimachinery v0.24.3
 	sigs.k8s.io/yaml v1.3.0
 )
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// clearedGuide replaces the guide when all the sig labels are removed.
+const clearedGuide = "All the SIG labels are removed. Please choose the SIGs by `/sig xxx`."
+
+// guideMarkerRegex matches the hidden marker appended to the guide comments,
+// which records the sigs the guide is for.
+var guideMarkerRegex = regexp.MustCompile(`<!-- sig-guide sigs=(\S*) -->`)
+
+// botLogin caches the login of the robot.
+type botLogin struct {
+	lock  sync.Mutex
+	login string
+}
+
+func (bot *robot) getBotLogin() (string, error) {
+	bot.login.lock.Lock()
+	defer bot.login.lock.Unlock()
+
+	if bot.login.login != "" {
+		return bot.login.login, nil
+	}
+
+	u, err := bot.cli.GetBot()
+	if err != nil {
+		return "", err
+	}
+
+	bot.login.login = u.Login
+
+	return u.Login, nil
+}
+
+func guideMarker(labels sets.String) string {
+	return fmt.Sprintf("\n<!-- sig-guide sigs=%s -->", strings.Join(labels.List(), ","))
+}
+
+// parseGuideMarker returns the sigs of the guide comment, ok is false if it is not a guide.
+func parseGuideMarker(comment string) (labels sets.String, ok bool) {
+	m := guideMarkerRegex.FindStringSubmatch(comment)
+	if m == nil {
+		return nil, false
+	}
+
+	labels = sets.NewString()
+	for _, l := range strings.Split(m[1], ",") {
+		if l != "" {
+			labels.Insert(l)
+		}
+	}
+
+	return labels, true
+}
+
+// guideComment is a comment of the issue or pull request, which may be a guide of the robot.
+type guideComment struct {
+	id        int32
+	author    string
+	body      string
+	createdAt string
+}
+
+// upsertGuide posts the guide for the sigs of labels rendered by gen, or edits the previous guide
+// if the sigs changed. It does nothing if the previous guide is for the same sigs.
+// It reports whether the guide is posted or edited.
+func (bot *robot) upsertGuide(
+	comments []guideComment, labels sets.String, gen func() (string, error),
+	create func(string) error, edit func(int32, string) error,
+) (bool, error) {
+	last, err := bot.lastGuide(comments)
+	if err != nil {
+		return false, err
+	}
+
+	if last != nil {
+		if v, _ := parseGuideMarker(last.body); v.Equal(labels) {
+			return false, nil
+		}
+	}
+
+	comment, err := gen()
+	if err != nil || comment == "" {
+		return false, err
+	}
+
+	comment += guideMarker(labels)
+
+	if last != nil {
+		return true, edit(last.id, comment)
+	}
+
+	return true, create(comment)
+}
+
+// lastGuide returns the last guide of the robot in the comments, or nil if there is none.
+func (bot *robot) lastGuide(comments []guideComment) (*guideComment, error) {
+	login, err := bot.getBotLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	var last *guideComment
+	for i := range comments {
+		if comments[i].author != login {
+			continue
+		}
+
+		if _, ok := parseGuideMarker(comments[i].body); ok {
+			last = &comments[i]
+		}
+	}
+
+	return last, nil
+}
+
+// clearGuide edits the previous guide to say no sig is left. It does nothing if there is no guide,
+// or the guide is cleared already.
+func (bot *robot) clearGuide(comments []guideComment, edit func(int32, string) error) error {
+	last, err := bot.lastGuide(comments)
+	if err != nil || last == nil {
+		return err
+	}
+
+	if v, _ := parseGuideMarker(last.body); v.Len() == 0 {
+		return nil
+	}
+
+	return edit(last.id, clearedGuide+guideMarker(sets.NewString()))
+}
+
+// clearIssueGuide clears the guide of the issue when all its sig labels are removed,
+// and watches it as the one without a sig.
+func (bot *robot) clearIssueGuide(org, repo, number string) error {
+	comments, err := bot.listIssueComments(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	err = bot.clearGuide(comments, func(id int32, comment string) error {
+		return bot.cli.UpdateIssueComment(org, repo, id, comment)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bot.watchItem(org, repo, number, false, nil)
+}
+
+// clearPRGuide is clearIssueGuide for the pull request.
+func (bot *robot) clearPRGuide(org, repo string, number int32) error {
+	comments, err := bot.listPRComments(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	err = bot.clearGuide(comments, func(id int32, comment string) error {
+		return bot.cli.UpdatePRComment(org, repo, id, comment)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bot.watchItem(org, repo, strconv.Itoa(int(number)), true, nil)
+}
+
+// upsertIssueGuide is upsertGuide for the issue.
+func (bot *robot) upsertIssueGuide(org, repo, number string, labels sets.String, gen func() (string, error)) (
+	bool, error,
+) {
+	comments, err := bot.listIssueComments(org, repo, number)
+	if err != nil {
+		return false, err
+	}
+
+	return bot.upsertGuide(
+		comments, labels, gen,
+		func(comment string) error {
+			return bot.cli.CreateIssueComment(org, repo, number, comment)
+		},
+		func(id int32, comment string) error {
+			return bot.cli.UpdateIssueComment(org, repo, id, comment)
+		},
+	)
+}
+
+// upsertPRGuide is upsertGuide for the pull request.
+func (bot *robot) upsertPRGuide(org, repo string, number int32, labels sets.String, gen func() (string, error)) (
+	bool, error,
+) {
+	comments, err := bot.listPRComments(org, repo, number)
+	if err != nil {
+		return false, err
+	}
+
+	return bot.upsertGuide(
+		comments, labels, gen,
+		func(comment string) error {
+			return bot.cli.CreatePRComment(org, repo, number, comment)
+		},
+		func(id int32, comment string) error {
+			return bot.cli.UpdatePRComment(org, repo, id, comment)
+		},
+	)
+}
+
+func (bot *robot) listIssueComments(org, repo, number string) ([]guideComment, error) {
+	v, err := bot.cli.ListIssueComments(org, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]guideComment, 0, len(v))
+	for i := range v {
+		comments = append(comments, guideComment{
+			id:        v[i].Id,
+			author:    userLogin(v[i].User),
+			body:      v[i].Body,
+			createdAt: v[i].CreatedAt,
+		})
+	}
+
+	return comments, nil
+}
+
+func (bot *robot) listPRComments(org, repo string, number int32) ([]guideComment, error) {
+	v, err := bot.cli.ListPRComments(org, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]guideComment, 0, len(v))
+	for i := range v {
+		comments = append(comments, guideComment{
+			id:        v[i].Id,
+			author:    userLogin(v[i].User),
+			body:      v[i].Body,
+			createdAt: v[i].CreatedAt,
+		})
+	}
+
+	return comments, nil
+}
+
+func userLogin(u *sdk.UserBasic) string {
+	if u == nil {
+		return ""
+	}
+
+	return u.Login
+}
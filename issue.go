@@ -1,153 +1,167 @@
 package main
 
 import (
-	"fmt"
 	sdk "github.com/opensourceways/go-gitee/gitee"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"strings"
-	"time"
 )
 
-func (bot *robot) dealIssueNote(e *sdk.NoteEvent, c *botConfig) error {
-	comment := e.GetComment().GetBody()
-	if !sigLabelRegex.MatchString(comment) {
-		return nil
-	}
-
+// dealIssueNote removes the sig labels of the /remove-sig command, and posts the guide of the sigs
+// chosen by the /sig command, or the guide of the remaining sigs if none is chosen.
+func (bot *robot) dealIssueNote(e *sdk.NoteEvent, c *botConfig, labels, rmLabels []string) error {
 	org, repo := e.GetOrgRepo()
 	number := e.GetIssueNumber()
 	author := e.GetIssueAuthor()
 
-	// all repos' issues can be changed labels by their authors
-	//if repo != "openGauss-server" {
-	//	return nil
-	//}
-
-	// time.Sleep(400 * time.Millisecond)
-	//labels, err := bot.cli.GetIssueLabels(org, repo, number)
-	//if err != nil {
-	//	return err
-	//}
+	apply := c.LabelIssueBySigCommand && len(labels) > 0
+	if len(rmLabels) > 0 || apply {
+		owned, err := bot.botAddedIssueLabels(c, org, repo, number)
+		if err != nil {
+			return err
+		}
 
-	sigNames := make(map[string]string, 0)
-	sigLabel := fmt.Sprintf("sig/%s", strings.Split(comment, " ")[1])
-	repositories := make(map[string][]RepoMember, 0)
-	deOwners := sets.NewString()
-	//for _, l := range labels {
-	//	if len(sigNames) > 0 {
-	//		break
-	//	}
-	//
-	//	if strings.HasPrefix(l.Name, "sig/") {
-	//		sigs, err := bot.decodeSigsContent()
-	//		if err != nil {
-	//			return err
-	//		}
-	//
-	//		for _, d := range sigs.DefaultOwners {
-	//			deOwners.Insert(d.GiteeID)
-	//		}
-	//
-	//		for _, sig := range sigs.Sigs {
-	//			if l.Name == sig.SigLabel {
-	//				sigNames[sig.Name] = sig.SigLink
-	//				repositories[sig.Name] = sig.Repos
-	//			}
-	//		}
-	//	}
-	//}
-
-	sigs, err := bot.decodeSigsContent()
-	if err != nil {
-		return err
-	}
+		rm := sets.NewString(rmLabels...)
+		if apply {
+			rm.Insert(owned.UnsortedList()...)
+		}
 
-	for _, d := range sigs.DefaultOwners {
-		deOwners.Insert(d.GiteeID)
-	}
+		current, remaining, err := bot.removeIssueSigLabels(org, repo, number, rm.Delete(labels...))
+		if err != nil {
+			return err
+		}
 
-	for _, sig := range sigs.Sigs {
-		if sigLabel == sig.SigLabel {
-			sigNames[sig.Name] = sig.SigLink
-			repositories[sig.Name] = sig.Repos
+		// the labels of the /sig command are chosen by hand, so they are never the robot's.
+		removed := rm
+		if apply {
+			removed = rm.Union(sets.NewString(labels...))
 		}
-	}
 
-	// firstly @ who to resolve this problem
-	owner := sets.NewString()
-	for _, r := range repositories {
-		if len(owner) > 0 {
-			break
+		// the record is seeded by the label of the robot, for the issue labeled before it is recorded.
+		if err := bot.recordBotLabels(issueKey(org, repo, number), current.Intersection(owned), removed); err != nil {
+			return err
 		}
 
-		for _, rp := range r {
-			for _, o := range rp.Owner {
-				owner.Insert(o.GiteeID)
+		if v := sets.NewString(labels...).Difference(remaining); apply && len(v) > 0 {
+			if err := bot.cli.AddMultiIssueLabel(org, repo, number, v.List()); err != nil {
+				return err
 			}
 		}
-	}
 
-	if len(owner) == 0 {
-		owner.Insert(deOwners.UnsortedList()...)
+		if len(labels) == 0 || apply {
+			labels = remaining.Insert(labels...).List()
+		}
 	}
 
-	maintainers := sets.NewString()
-	committers := sets.NewString()
-	for sn := range sigNames {
-		if c.CustomizeMembers {
-			os, cs, err := bot.decodeSpecialOWNERSContent(sn, org, repo)
-			if err != nil {
-				return err
-			}
-
-			maintainers.Insert(os...)
-			committers.Insert(cs...)
-			continue
+	if len(labels) == 0 {
+		if len(rmLabels) == 0 {
+			return nil
 		}
 
-		os, cs, err := bot.decodeOWNERSContent(sn)
+		// all the sig labels are removed.
+		return bot.clearIssueGuide(org, repo, number)
+	}
+
+	// the sigs are in the order of their labels, so that the guide is the same every time.
+	sigsData := make([]sigData, 0, len(labels))
+	for _, l := range sets.NewString(labels...).List() {
+		g, err := bot.genSigGuide(c, org, repo, l, nil)
 		if err != nil {
 			return err
 		}
 
-		maintainers.Insert(os...)
-		committers.Insert(cs...)
+		if g.Name != "" {
+			sigsData = append(sigsData, g)
+		}
 	}
 
-	// remove duplicate
-	//for o := range owner {
-	//	for j := range maintainers {
-	//		if o == j {
-	//			maintainers.Delete(j)
-	//		}
-	//	}
-	//	for n := range committers {
-	//		if o == n {
-	//			committers.Delete(n)
-	//		}
-	//	}
-	//}
+	if len(sigsData) == 0 {
+		return nil
+	}
 
-	// gen hyper link in messages
-	sigsLinks := make([]string, 0)
-	for k, v := range sigNames {
-		sigsLinks = append(sigsLinks, fmt.Sprintf(sigLink, k, v))
+	owners, maintainers, committers := sets.NewString(), sets.NewString(), sets.NewString()
+	for i := range sigsData {
+		owners.Insert(sigsData[i].Owners...)
+		maintainers.Insert(sigsData[i].Maintainers...)
+		committers.Insert(sigsData[i].Committers...)
 	}
 
-	if len(maintainers) == 0 || len(committers) == 0 || len(sigsLinks) == 0 {
-		return nil
+	data := &messageData{
+		item:        issueKey(org, repo, number),
+		Author:      author,
+		Owners:      owners.List(),
+		Maintainers: maintainers.List(),
+		Committers:  committers.List(),
+		Sigs:        sigsData,
+	}
+
+	guided := sets.NewString()
+	for i := range sigsData {
+		guided.Insert(sigsData[i].Label)
+	}
+
+	posted, err := bot.upsertIssueGuide(org, repo, number, guided, func() (string, error) {
+		return bot.genMessage(c, tmplIssueGuide, e.GetIssue().Body, data)
+	})
+	if err != nil || !posted {
+		return err
 	}
 
-	message := fmt.Sprintf(forIssueReply, author, strings.Join(owner.UnsortedList(), " , @"),
-		strings.Join(maintainers.UnsortedList(), " , @"), strings.Join(committers.UnsortedList(), " , @"),
-		strings.Join(sigsLinks, ""))
+	if err := bot.watchItem(org, repo, number, false, data); err != nil {
+		return err
+	}
+
+	return bot.assignIssue(c, org, repo, number, data)
+}
+
+// botAddedIssueLabels returns the sig labels which the robot has added to the issue, the others are
+// regarded as being added by hand. For the one labeled before the labels are recorded, it is the label
+// which the robot may have added to the issues of the repository.
+func (bot *robot) botAddedIssueLabels(bc *botConfig, org, repo, number string) (sets.String, error) {
+	if v, ok := bot.recordedBotLabels(issueKey(org, repo, number)); ok {
+		return v, nil
+	}
+
+	label, _, _, _, _, err := bot.genIssueSigLabel(bc, repo)
+	if err != nil || label == "" {
+		return sets.NewString(), err
+	}
 
-	time.Sleep(500 * time.Millisecond)
-	return bot.cli.CreateIssueComment(org, repo, number, message)
+	return sets.NewString(label), nil
 }
 
-func (bot *robot) genIssueSigLabel(repo string) (string, string, string, sets.String, sets.String, error) {
-	sigs, err := bot.decodeSigsContent()
+// removeIssueSigLabels removes the labels from the issue, and returns the sig labels on the issue
+// before the removal and the remaining ones.
+func (bot *robot) removeIssueSigLabels(org, repo, number string, labels sets.String) (
+	sets.String, sets.String, error,
+) {
+	v, err := bot.cli.GetIssueLabels(org, repo, number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current := sets.NewString()
+	remaining := sets.NewString()
+	for _, l := range v {
+		if !strings.HasPrefix(l.Name, "sig/") {
+			continue
+		}
+
+		current.Insert(l.Name)
+		if !labels.Has(l.Name) {
+			remaining.Insert(l.Name)
+			continue
+		}
+
+		if err := bot.cli.RemoveIssueLabel(org, repo, number, l.Name); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return current, remaining, nil
+}
+
+func (bot *robot) genIssueSigLabel(bc *botConfig, repo string) (string, string, string, sets.String, sets.String, error) {
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
 	if err != nil {
 		return "", "", "", nil, nil, err
 	}
@@ -187,3 +201,34 @@ func (bot *robot) genIssueSigLabel(repo string) (string, string, string, sets.St
 
 	return "", "", "", nil, nil, err
 }
+
+// genSigNotice generates the message which asks the author to choose a sig by the /sig command.
+func (bot *robot) genSigNotice(bc *botConfig, author, text string) (string, error) {
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
+	if err != nil {
+		return "", err
+	}
+
+	deOwners := sets.NewString()
+	for _, d := range sigs.DefaultOwners {
+		deOwners.Insert(d.GiteeID)
+	}
+
+	examples := make([]string, 0, maxSigExamples)
+	for _, s := range sigs.Sigs {
+		if len(examples) == maxSigExamples {
+			break
+		}
+
+		if v := strings.TrimPrefix(s.SigLabel, "sig/"); v != "" {
+			examples = append(examples, v)
+		}
+	}
+
+	return bot.genMessage(bc, tmplSigNotice, text, &messageData{
+		Author:   author,
+		Owners:   deOwners.List(),
+		Examples: examples,
+		SigsURL:  bc.Templates.SigsURL,
+	})
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/opensourceways/community-robot-lib/config"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	liboptions "github.com/opensourceways/community-robot-lib/options"
+	"github.com/opensourceways/community-robot-lib/secret"
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	defaultSigLabelColor = "1e90ff"
+
+	labelCreated = "created"
+	labelUpdated = "updated"
+)
+
+// labelSyncConfig is how to make sure the repositories of sigs have their sig labels,
+// so that labeling never fails or creates the labels in the default color.
+// The repositories are the ones of the config items, each with the relationship of its config item.
+// Gitee doesn't support the description of labels, so the link of sig is not synced.
+type labelSyncConfig struct {
+	// Enable means syncing the labels at startup and when the relationship changes
+	Enable bool `json:"enable,omitempty"`
+
+	// Color is the color of the sig labels in the form of rrggbb, default is 1e90ff
+	Color string `json:"color,omitempty"`
+}
+
+func (c *labelSyncConfig) setDefault() {
+	if c.Color == "" {
+		c.Color = defaultSigLabelColor
+	}
+}
+
+func (c *labelSyncConfig) validate() error {
+	if !isColor(c.Color) {
+		return fmt.Errorf("invalid color of label_sync: %s", c.Color)
+	}
+
+	return nil
+}
+
+func isColor(s string) bool {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return false
+	}
+
+	for _, c := range strings.ToLower(s) {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameColor(a, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "#"), strings.TrimPrefix(b, "#"))
+}
+
+// labelChange is a label created or updated in a repository.
+type labelChange struct {
+	repo   string
+	label  string
+	action string
+}
+
+func (c labelChange) String() string {
+	return fmt.Sprintf("%s %s: %s", c.action, c.label, c.repo)
+}
+
+// sigLabelsOfRepos returns the sig labels which each repository should have, that is
+// the labels of the sigs which the repository or the file rules in it belong to.
+func sigLabelsOfRepos(sigs *SigYaml) map[string]sets.String {
+	r := make(map[string]sets.String)
+	add := func(repo, label string) {
+		if repo == "" || hasMeta(repo) {
+			return
+		}
+
+		if _, ok := r[repo]; !ok {
+			r[repo] = sets.NewString()
+		}
+		r[repo].Insert(label)
+	}
+
+	for i := range sigs.Sigs {
+		s := &sigs.Sigs[i]
+		if !strings.HasPrefix(s.SigLabel, "sig/") {
+			continue
+		}
+
+		for _, rm := range s.Repos {
+			for _, repo := range rm.Repo {
+				add(repo, s.SigLabel)
+			}
+		}
+
+		for _, fm := range s.Files {
+			for _, f := range fm.File {
+				add(strings.SplitN(f, "/", 2)[0], s.SigLabel)
+			}
+		}
+	}
+
+	return r
+}
+
+// syncLabels makes sure each repository of sigs has the sig labels in the color. The repositories of
+// each relation source are looked for in the organizations of the config items which use it.
+// It only reports the changes without doing them if dryRun is true.
+func (bot *robot) syncLabels(c *configuration, dryRun bool) ([]labelChange, error) {
+	var changes []labelChange
+	var errs []error
+	for _, src := range c.relationSources() {
+		src := src
+
+		sigs, err := bot.decodeSigsContent(&src)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		repoLabels := sigLabelsOfRepos(sigs)
+		for _, org := range c.orgsOf(&src) {
+			for repo, labels := range repoLabels {
+				if !c.usesSource(org, repo, &src) {
+					continue
+				}
+
+				v, err := bot.syncRepoLabels(org, repo, c.LabelSync.Color, labels, dryRun)
+				changes = append(changes, v...)
+
+				if err != nil {
+					errs = append(errs, fmt.Errorf("sync labels of %s/%s: %v", org, repo, err))
+				}
+			}
+		}
+	}
+
+	return changes, utilerrors.NewAggregate(errs)
+}
+
+func (bot *robot) syncRepoLabels(org, repo, color string, labels sets.String, dryRun bool) (
+	[]labelChange, error,
+) {
+	current, err := bot.cli.GetRepoLabels(org, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make(map[string]string, len(current))
+	for _, l := range current {
+		colors[l.Name] = l.Color
+	}
+
+	color = strings.TrimPrefix(color, "#")
+
+	var changes []labelChange
+	for _, l := range labels.List() {
+		old, ok := colors[l]
+		if ok && sameColor(old, color) {
+			continue
+		}
+
+		change := labelChange{repo: org + "/" + repo, label: l, action: labelUpdated}
+		if !ok {
+			change.action = labelCreated
+		}
+
+		if !dryRun {
+			if ok {
+				err = bot.cli.UpdateRepoLabel(org, repo, l, l, color)
+			} else {
+				err = bot.cli.CreateRepoLabel(org, repo, l, color)
+			}
+
+			if err != nil {
+				return changes, err
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// syncLabelsInBackground syncs the labels if it is enabled, and does nothing if a sync is running.
+func (bot *robot) syncLabelsInBackground(cfg config.Config) {
+	c, ok := cfg.(*configuration)
+	if !ok || c == nil || !c.LabelSync.Enable {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&bot.labelSyncing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&bot.labelSyncing, 0)
+
+		changes, err := bot.syncLabels(c, false)
+		for _, v := range changes {
+			logrus.Infof("sync labels: %s", v)
+		}
+
+		if err != nil {
+			logrus.WithError(err).Error("sync labels")
+		}
+	}()
+}
+
+// runSyncLabels syncs the sig labels with the relationship in a local checkout of the tc repository.
+func runSyncLabels(args []string) int {
+	fs := flag.NewFlagSet("sync-labels", flag.ExitOnError)
+
+	var gitee liboptions.GiteeOptions
+	gitee.AddFlags(fs)
+
+	var c labelSyncConfig
+	var src relationSource
+	dir := addLocalRelationFlags(fs, &src)
+	org := fs.String("org", "opengauss", "The organization of the repositories of sigs.")
+	fs.StringVar(&c.Color, "color", "", "The color of the sig labels in the form of rrggbb, default is "+defaultSigLabelColor+".")
+	dryRun := fs.Bool("dry-run", false, "Only print the labels to create or update.")
+
+	fs.Parse(args)
+	c.setDefault()
+	src.setDefault()
+
+	if err := gitee.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 2
+	}
+
+	if err := c.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 2
+	}
+
+	if err := src.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 2
+	}
+
+	secretAgent := new(secret.Agent)
+	if err := secretAgent.Start([]string{gitee.TokenPath}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 1
+	}
+
+	defer secretAgent.Stop()
+
+	cli := giteeclient.NewClient(secretAgent.GetTokenGenerator(gitee.TokenPath))
+	bot := &robot{
+		cli:   newLimitedClient(cli, defaultClientOptions),
+		cache: newRelationCache(time.Hour),
+		store: &stateStore{},
+		local: dirLoader(*dir),
+	}
+
+	cfg := &configuration{
+		ConfigItems: []botConfig{{RepoFilter: config.RepoFilter{Repos: []string{*org}}, RelationSource: src}},
+		LabelSync:   c,
+	}
+
+	changes, err := bot.syncLabels(cfg, *dryRun)
+	for _, v := range changes {
+		fmt.Println(v)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// lintProblem is a problem of the relationship found by the linter.
+type lintProblem struct {
+	file string
+	line int
+	msg  string
+}
+
+func (p lintProblem) String() string {
+	if p.line == 0 {
+		return fmt.Sprintf("%s: %s", p.file, p.msg)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", p.file, p.line, p.msg)
+}
+
+// relationLinter checks the relationship file and the OWNERS files of the sigs,
+// which only fail silently when the robot runs.
+type relationLinter struct {
+	load fileLoader
+	src  *relationSource
+
+	// allowMultiSigRepos means a repository can belong to more than one sig,
+	// which is the case of manual_sig.multi_sig_repos
+	allowMultiSigRepos bool
+
+	problems []lintProblem
+}
+
+func (l *relationLinter) report(file string, n *yamlv3.Node, format string, a ...interface{}) {
+	line := 0
+	if n != nil {
+		line = n.Line
+	}
+
+	l.problems = append(l.problems, lintProblem{file: file, line: line, msg: fmt.Sprintf(format, a...)})
+}
+
+// parse loads the file into v in the same way as the robot,
+// and returns the yaml node of it to locate the problems.
+func (l *relationLinter) parse(file string, v interface{}) (*yamlv3.Node, error) {
+	b, err := l.load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(yamlv3.Node)
+	if err := yamlv3.Unmarshal(b, n); err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (l *relationLinter) lint() {
+	sigs, root := l.lintSigs()
+	if sigs == nil {
+		return
+	}
+
+	names := map[string]bool{}
+	for i := range sigs.Sigs {
+		name := sigs.Sigs[i].Name
+		if name == "" || names[name] {
+			continue
+		}
+		names[name] = true
+
+		l.lintOWNERS(name, yamlNode(root, "sigs", i, "name"))
+	}
+}
+
+// lintSigs checks the relationship file, and returns it and its yaml node.
+func (l *relationLinter) lintSigs() (*SigYaml, *yamlv3.Node) {
+	file := l.src.Path
+
+	var sigs SigYaml
+	root, err := l.parse(file, &sigs)
+	if err != nil {
+		l.report(file, nil, "%v", err)
+
+		return nil, nil
+	}
+
+	if len(sigs.DefaultOwners) == 0 {
+		l.report(file, yamlNode(root, "default_owners"), "default_owners is empty")
+	}
+	l.lintMembers(file, root, sigs.DefaultOwners, "default_owners")
+
+	// claims are the names and labels which can be used in the /sig command, and the sigs of them.
+	// The key is in lower case, since they are resolved regardless of the case.
+	claims := map[string]int{}
+	labels := map[string]int{}
+	for i := range sigs.Sigs {
+		sig := &sigs.Sigs[i]
+		node := yamlNode(root, "sigs", i)
+
+		if sig.Name == "" {
+			l.report(file, node, "sig has no name")
+		} else if j, ok := claims[claimKey(sig.Name)]; ok && strings.EqualFold(sigs.Sigs[j].Name, sig.Name) {
+			l.report(file, yamlNode(node, "name"), "sig %s is also defined at line %d",
+				sig.Name, yamlNode(root, "sigs", j, "name").Line)
+		} else {
+			claims[claimKey(sig.Name)] = i
+		}
+
+		switch label := sig.SigLabel; {
+		case label == "":
+			l.report(file, node, "sig %s has no sig_label", sig.Name)
+
+		case !strings.HasPrefix(label, "sig/"):
+			l.report(file, yamlNode(node, "sig_label"),
+				"sig_label %s of sig %s does not start with sig/, so it is not handled as a sig label", label, sig.Name)
+
+		default:
+			if j, ok := labels[label]; ok {
+				l.report(file, yamlNode(node, "sig_label"), "sig_label %s is also used by sig %s", label, sigs.Sigs[j].Name)
+			} else {
+				labels[label] = i
+			}
+
+			if v := claimKey(label); v != claimKey(sig.Name) {
+				if _, ok := claims[v]; !ok {
+					claims[v] = i
+				}
+			}
+		}
+
+		if sig.SigLink == "" {
+			l.report(file, node, "sig %s has no sig_link", sig.Name)
+		}
+	}
+
+	repos := map[string]int{}
+	for i := range sigs.Sigs {
+		sig := &sigs.Sigs[i]
+		node := yamlNode(root, "sigs", i)
+
+		for j, a := range sig.Aliases {
+			a = trimSigPrefix(a)
+			if k, ok := claims[claimKey(a)]; ok && k != i {
+				l.report(file, yamlNode(node, "aliases", j),
+					"alias %s of sig %s is also the name, label or alias of sig %s", a, sig.Name, sigs.Sigs[k].Name)
+			} else {
+				claims[claimKey(a)] = i
+			}
+		}
+
+		for j := range sig.Files {
+			fm := &sig.Files[j]
+			n := yamlNode(node, "files", j)
+
+			if len(fm.File) == 0 {
+				l.report(file, n, "files[%d] of sig %s has no file", j, sig.Name)
+			}
+
+			for k, f := range fm.File {
+				if f == "" {
+					l.report(file, yamlNode(n, "file", k), "empty file pattern of sig %s", sig.Name)
+				} else if err := checkPattern(f); err != nil {
+					l.report(file, yamlNode(n, "file", k), "file pattern %s of sig %s is malformed", f, sig.Name)
+				}
+			}
+
+			if len(fm.Owner) == 0 {
+				l.report(file, n, "files[%d] of sig %s has no owner", j, sig.Name)
+			}
+			l.lintMembers(file, root, fm.Owner, "sigs", i, "files", j, "owner")
+		}
+
+		for j := range sig.Repos {
+			rm := &sig.Repos[j]
+			n := yamlNode(node, "repos", j)
+
+			if len(rm.Repo) == 0 {
+				l.report(file, n, "repos[%d] of sig %s has no repo", j, sig.Name)
+			}
+
+			for k, r := range rm.Repo {
+				rn := yamlNode(n, "repo", k)
+				if r == "" {
+					l.report(file, rn, "empty repository of sig %s", sig.Name)
+
+					continue
+				}
+
+				s, ok := repos[r]
+				if !ok {
+					repos[r] = i
+
+					continue
+				}
+
+				if s == i {
+					l.report(file, rn, "repository %s is listed more than once in sig %s", r, sig.Name)
+				} else if !l.allowMultiSigRepos {
+					l.report(file, rn, "repository %s is also in sig %s, only the first sig is used", r, sigs.Sigs[s].Name)
+				}
+			}
+
+			if len(rm.Owner) == 0 {
+				l.report(file, n, "repos[%d] of sig %s has no owner", j, sig.Name)
+			}
+			l.lintMembers(file, root, rm.Owner, "sigs", i, "repos", j, "owner")
+		}
+	}
+
+	return &sigs, root
+}
+
+// claimKey returns the key of the name, label or alias of a sig in the claims.
+func claimKey(name string) string {
+	return strings.ToLower(trimSigPrefix(name))
+}
+
+func (l *relationLinter) lintMembers(file string, root *yamlv3.Node, members []Member, path ...interface{}) {
+	for i := range members {
+		if members[i].GiteeID == "" {
+			l.report(file, yamlNode(root, append(path, i)...), "member has no gitee_id")
+		}
+	}
+}
+
+// lintOWNERS checks the OWNERS file of the sig, which can be in the form of OWNERS or SpecialOWNERS.
+// The missing file is reported at the sig in the relationship file.
+func (l *relationLinter) lintOWNERS(sigName string, sigNode *yamlv3.Node) {
+	file := l.src.ownersPath(sigName)
+
+	var o struct {
+		OWNERS
+		SpecialOWNERS
+	}
+	root, err := l.parse(file, &o)
+	if err != nil {
+		if os.IsNotExist(err) {
+			l.report(l.src.Path, sigNode, "%s of sig %s is missing", file, sigName)
+		} else {
+			l.report(file, nil, "%v", err)
+		}
+
+		return
+	}
+
+	if len(o.Repositories) == 0 {
+		if len(o.Maintainers) == 0 {
+			l.report(file, yamlNode(root, "maintainers"), "no maintainers")
+		}
+
+		l.lintIDs(file, root, o.Maintainers, "maintainers")
+		l.lintIDs(file, root, o.Committers, "committers")
+
+		return
+	}
+
+	for i := range o.Repositories {
+		r := &o.Repositories[i]
+		n := yamlNode(root, "repositories", i)
+
+		if len(r.Repo) == 0 {
+			l.report(file, n, "repositories[%d] has no repo", i)
+		}
+
+		if len(r.Maintainers) == 0 {
+			l.report(file, n, "repositories[%d] has no maintainers", i)
+		}
+
+		l.lintIDs(file, root, r.Maintainers, "repositories", i, "maintainers")
+		l.lintIDs(file, root, r.Committers, "repositories", i, "committers")
+	}
+}
+
+func (l *relationLinter) lintIDs(file string, root *yamlv3.Node, ids []string, path ...interface{}) {
+	for i, v := range ids {
+		if v == "" {
+			l.report(file, yamlNode(root, append(path, i)...), "empty gitee id")
+		}
+	}
+}
+
+// yamlNode returns the node at the path of mapping keys and sequence indexes,
+// or the deepest node found if the path doesn't exist, so the problem is located as close as possible.
+func yamlNode(n *yamlv3.Node, path ...interface{}) *yamlv3.Node {
+	if n.Kind == yamlv3.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	for _, p := range path {
+		var next *yamlv3.Node
+
+		switch k := p.(type) {
+		case string:
+			if n.Kind == yamlv3.MappingNode {
+				for i := 0; i+1 < len(n.Content); i += 2 {
+					// the keys are matched case-insensitively as the json tags
+					if strings.EqualFold(n.Content[i].Value, k) {
+						next = n.Content[i+1]
+
+						break
+					}
+				}
+			}
+
+		case int:
+			if n.Kind == yamlv3.SequenceNode && k < len(n.Content) {
+				next = n.Content[k]
+			}
+		}
+
+		if next == nil {
+			return n
+		}
+
+		n = next
+	}
+
+	return n
+}
+
+// runLint checks the relationship in a local checkout of the tc repository,
+// prints the problems and returns 1 if there is any.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+
+	var src relationSource
+	dir := addLocalRelationFlags(fs, &src)
+	allowMultiSigRepos := fs.Bool(
+		"allow-multi-sig-repos", false,
+		"Allow a repository to belong to more than one sig, which is the case of manual_sig.multi_sig_repos.",
+	)
+
+	fs.Parse(args)
+	src.setDefault()
+
+	l := relationLinter{
+		load:               dirLoader(*dir),
+		src:                &src,
+		allowMultiSigRepos: *allowMultiSigRepos,
+	}
+	l.lint()
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		a, b := &l.problems[i], &l.problems[j]
+		if a.file != b.file {
+			return a.file < b.file
+		}
+
+		return a.line < b.line
+	})
+
+	for _, p := range l.problems {
+		fmt.Println(p)
+	}
+
+	if n := len(l.problems); n > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", n)
+
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// fileLoader loads a file of the relationship, the path is relative to the root of the tc repository.
+type fileLoader func(path string) ([]byte, error)
+
+// dirLoader loads the files from a local checkout of the tc repository.
+func dirLoader(dir string) fileLoader {
+	return func(path string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	}
+}
+
+func loadYaml(load fileLoader, path string, v interface{}) error {
+	b, err := load(path)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(b, v)
+}
+
+// addLocalRelationFlags adds the flags of the relationship in a local checkout of the tc repository
+// to the subcommand, and returns the path of the checkout.
+func addLocalRelationFlags(fs *flag.FlagSet, src *relationSource) *string {
+	dir := fs.String("dir", ".", "Path to the local checkout of the tc repository.")
+
+	fs.StringVar(
+		&src.Path, "relationship-path", "",
+		"Path of the relationship file in the repository, default is gauss_relationship.yaml.",
+	)
+
+	fs.StringVar(
+		&src.OwnersPath, "owners-path", "",
+		"Pattern of the path of sig's OWNERS file in the repository, default is sigs/%s/OWNERS.",
+	)
+
+	return dir
+}
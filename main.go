@@ -2,8 +2,11 @@ package main
 
 import (
 	"flag"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/opensourceways/community-robot-lib/config"
 	"github.com/opensourceways/community-robot-lib/giteeclient"
 	"github.com/opensourceways/community-robot-lib/logrusutil"
 	liboptions "github.com/opensourceways/community-robot-lib/options"
@@ -15,6 +18,11 @@ import (
 type options struct {
 	service liboptions.ServiceOptions
 	gitee   liboptions.GiteeOptions
+
+	cacheTTL           time.Duration
+	stateFile          string
+	client             clientOptions
+	escalationInterval time.Duration
 }
 
 func (o *options) Validate() error {
@@ -31,11 +39,60 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	o.gitee.AddFlags(fs)
 	o.service.AddFlags(fs)
 
+	fs.DurationVar(
+		&o.cacheTTL, "relationship-cache-ttl", 10*time.Minute,
+		"How long the relationship data fetched from the tc repository is cached, 0 means no cache.",
+	)
+
+	fs.Float64Var(
+		&o.client.qps, "gitee-qps", defaultClientOptions.qps,
+		"The max number of requests per second sent to gitee, 0 means no limit.",
+	)
+
+	fs.IntVar(
+		&o.client.burst, "gitee-burst", defaultClientOptions.burst,
+		"The max number of requests sent to gitee at once.",
+	)
+
+	fs.IntVar(
+		&o.client.maxRetries, "gitee-max-retries", defaultClientOptions.maxRetries,
+		"The max number of retries of a request to gitee which failed with 429 or 5xx.",
+	)
+
+	fs.DurationVar(
+		&o.client.retryBase, "gitee-retry-backoff", defaultClientOptions.retryBase,
+		"The backoff of the first retry of a request to gitee, it doubles for each of the following ones.",
+	)
+
+	fs.DurationVar(
+		&o.escalationInterval, "escalation-interval", time.Hour,
+		"How often to check the issues and pull requests waiting for the response of owners or the fallback of manual_sig, "+
+			"0 means no escalation, in which case the fallback is checked every minute.",
+	)
+
+	fs.StringVar(
+		&o.stateFile, "state-file", "",
+		"Path to the file which keeps the state of the robot across restarts, the state is only kept in memory if empty.",
+	)
+
 	fs.Parse(args)
 	return o
 }
 
+// subcommands are the tools run instead of the robot, e.g. sig-guide lint --dir ./tc
+var subcommands = map[string]func(args []string) int{
+	"lint":        runLint,
+	"who-owns":    runWhoOwns,
+	"sync-labels": runSyncLabels,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
 	logrusutil.ComponentInit(botName)
 
 	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
@@ -52,7 +109,34 @@ func main() {
 
 	c := giteeclient.NewClient(secretAgent.GetTokenGenerator(o.gitee.TokenPath))
 
-	p := newRobot(c)
+	store, err := newStateStore(o.stateFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error loading the state.")
+	}
+
+	p := newRobot(newLimitedClient(c, o.client), o.cacheTTL, store)
+
+	// The config agent of framework.Run is not exposed, so the api, the escalation, the digest and the label sync
+	// read the same file by another agent. Both agents reload it on change, so they may see different versions
+	// for a moment during a reload. That is harmless since each of them reads the config once for each run,
+	// and the state they share is kept in the state store instead of the config.
+	configAgent := config.NewConfigAgent(p.NewConfig)
+	if err := configAgent.Start(o.service.ConfigFile); err != nil {
+		logrus.WithError(err).Fatal("Error starting config agent.")
+	}
+
+	defer configAgent.Stop()
+
+	// framework.Run serves on http.DefaultServeMux, so the api and metrics are served along with the webhook.
+	newAPIServer(p, &configAgent).register(http.DefaultServeMux)
+	http.Handle("/metrics", botMetrics.handler(p.cache))
+
+	go p.runEscalation(&configAgent, o.escalationInterval)
+
+	go p.runDigest(&configAgent)
+
+	_, cfg := configAgent.GetConfig()
+	p.syncLabelsInBackground(cfg)
 
 	framework.Run(p, o.service)
 }
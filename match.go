@@ -0,0 +1,114 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// exactMatch is added to the score of a pattern which is equal to the file,
+// so that an exact match always wins.
+const exactMatch = 1 << 20
+
+// matchFile reports whether the pattern of FileMember.File matches the file,
+// both of which are in the form of repo/path/to/file. It returns 0 if not matched,
+// otherwise the specificity of the pattern.
+//
+// A pattern can be:
+//   - a file, e.g. openGauss-server/src/Makefile, which only matches itself.
+//   - a directory, e.g. openGauss-server/src/gausskernel/storage/ or
+//     openGauss-server/src/gausskernel/storage, which matches all the files under it.
+//   - a gitignore-style glob, e.g. openGauss-server/src/**/*.cpp, in which
+//     '*', '?' and '[...]' match within one segment of the path, and '**' matches
+//     zero or more segments.
+//
+// The precedence of patterns which match the same file is:
+//  1. a pattern equal to the file wins.
+//  2. otherwise the pattern with more literal characters (wildcards are not counted) wins.
+//  3. if still tied, the pattern which appears first in the relationship file wins.
+func matchFile(pattern, file string) int {
+	if pattern == "" {
+		return 0
+	}
+
+	if pattern == file {
+		return exactMatch + len(pattern)
+	}
+
+	score := literalLen(pattern)
+
+	if !hasMeta(pattern) {
+		dir := strings.TrimSuffix(pattern, "/")
+		if strings.HasPrefix(file, dir+"/") {
+			return score
+		}
+
+		return 0
+	}
+
+	p := strings.TrimSuffix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		// a directory glob matches everything under the directories.
+		p += "/**"
+	}
+
+	if matchSegments(strings.Split(p, "/"), strings.Split(file, "/")) {
+		return score
+	}
+
+	return 0
+}
+
+func matchSegments(pattern, file []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(file); i++ {
+				if matchSegments(rest, file[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(file) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(pattern[0], file[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern, file = pattern[1:], file[1:]
+	}
+
+	return len(file) == 0
+}
+
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+func literalLen(pattern string) int {
+	n := 0
+	for _, c := range pattern {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+		default:
+			n++
+		}
+	}
+
+	return n
+}
+
+// checkPattern returns an error if the pattern is malformed, in which case it matches nothing.
+func checkPattern(pattern string) error {
+	for _, s := range strings.Split(pattern, "/") {
+		if _, err := path.Match(s, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import "testing"
+
+func TestMatchFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		file    string
+		matched bool
+	}{
+		{"file itself", "r/src/Makefile", "r/src/Makefile", true},
+		{"other file", "r/src/Makefile", "r/src/Makefile.in", false},
+		{"empty pattern", "", "r/src/Makefile", false},
+
+		{"directory with slash", "r/src/", "r/src/a/b.c", true},
+		{"directory without slash", "r/src", "r/src/a/b.c", true},
+		{"directory is not a prefix of the name", "r/src", "r/srcs/b.c", false},
+		{"directory with slash is not a prefix of the name", "r/src/", "r/srcs/b.c", false},
+
+		{"leading ** matches zero segment", "**/*.c", "a.c", true},
+		{"leading ** matches many segments", "**/*.c", "r/src/a/b.c", true},
+		{"middle ** matches zero segment", "r/src/**/*.cpp", "r/src/a.cpp", true},
+		{"middle ** matches many segments", "r/src/**/*.cpp", "r/src/a/b/c.cpp", true},
+		{"middle ** keeps the prefix", "r/src/**/*.cpp", "r/test/a.cpp", false},
+		{"trailing ** matches everything under", "r/src/**", "r/src/a/b.c", true},
+		{"trailing ** does not match the other directory", "r/src/**", "r/test/a.c", false},
+
+		{"* within one segment", "r/src/*.c", "r/src/a.c", true},
+		{"* does not cross segments", "r/src/*.c", "r/src/a/b.c", false},
+		{"? matches one character", "r/src/?.c", "r/src/a.c", true},
+		{"class", "r/src/[ab].c", "r/src/b.c", true},
+		{"glob directory with slash", "r/src/*/", "r/src/a/b/c.c", true},
+		{"glob directory without slash only matches the segment", "r/src/*", "r/src/a/b.c", false},
+
+		{"malformed class", "r/src/[a.c", "r/src/[a.c", true},
+		{"malformed class matches nothing else", "r/src/[a.c", "r/src/a.c", false},
+		{"malformed escape", `r/src/a\`, "r/src/a", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matchFile(c.pattern, c.file) > 0; got != c.matched {
+				t.Errorf("matchFile(%q, %q) = %v, want %v", c.pattern, c.file, got, c.matched)
+			}
+		})
+	}
+}
+
+func TestMatchFilePrecedence(t *testing.T) {
+	rule := func(name string, patterns ...string) Sig {
+		return Sig{Name: name, SigLabel: "sig/" + name, Files: []FileMember{{File: patterns}}}
+	}
+
+	cases := []struct {
+		name string
+		sigs []Sig
+		file string
+		want string
+	}{
+		{
+			name: "exact match wins over a longer glob",
+			sigs: []Sig{rule("glob", "r/**/src/Makefile"), rule("exact", "r/src/Makefile")},
+			file: "r/src/Makefile",
+			want: "exact",
+		},
+		{
+			name: "exact match wins over a directory",
+			sigs: []Sig{rule("dir", "r/src/"), rule("exact", "r/src/a.c")},
+			file: "r/src/a.c",
+			want: "exact",
+		},
+		{
+			name: "deeper directory wins",
+			sigs: []Sig{rule("top", "r/src/"), rule("deep", "r/src/storage/")},
+			file: "r/src/storage/a.c",
+			want: "deep",
+		},
+		{
+			name: "more literal characters win",
+			sigs: []Sig{rule("any", "r/**/*.c"), rule("src", "r/src/**/*.c")},
+			file: "r/src/a/b.c",
+			want: "src",
+		},
+		{
+			name: "wildcards are not counted",
+			sigs: []Sig{rule("wild", "r/*/*.c"), rule("dir", "r/src/")},
+			file: "r/src/a.c",
+			want: "dir",
+		},
+		{
+			name: "first sig wins the tie",
+			sigs: []Sig{rule("first", "r/src/*.c"), rule("second", "r/src/a.*")},
+			file: "r/src/a.c",
+			want: "first",
+		},
+		{
+			name: "first sig wins the tie in the other order",
+			sigs: []Sig{rule("second", "r/src/a.*"), rule("first", "r/src/*.c")},
+			file: "r/src/a.c",
+			want: "second",
+		},
+		{
+			name: "no match",
+			sigs: []Sig{rule("a", "r/src/")},
+			file: "r/test/a.c",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sigs := &SigYaml{Sigs: c.sigs}
+
+			got := ""
+			if s := sigs.matchFile(c.file); s != nil {
+				got = s.Name
+			}
+
+			if got != c.want {
+				t.Errorf("got sig %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCheckPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"r/src/**/*.c", true},
+		{"r/src/[ab].c", true},
+		{"r/src/", true},
+		{"r/src/[a.c", false},
+		{`r/src/a\`, false},
+	}
+
+	for _, c := range cases {
+		if err := checkPattern(c.pattern); (err == nil) != c.valid {
+			t.Errorf("checkPattern(%q) = %v, want valid: %v", c.pattern, err, c.valid)
+		}
+	}
+}
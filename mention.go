@@ -0,0 +1,57 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// dedupMentions removes the author and the members which appear in the previous tiers from each tier,
+// and sorts them.
+func dedupMentions(author string, tiers ...*[]string) {
+	seen := sets.NewString(author)
+
+	for _, t := range tiers {
+		v := sets.NewString(*t...).Difference(seen).List()
+		seen.Insert(*t...)
+
+		*t = v
+	}
+}
+
+// capMentions caps each tier to limit(not positive means no limit). It reports whether any tier is capped.
+func capMentions(limit int, tiers ...*[]string) bool {
+	capped := false
+
+	for _, t := range tiers {
+		if limit > 0 && len(*t) > limit {
+			*t = (*t)[:limit]
+			capped = true
+		}
+	}
+
+	return capped
+}
+
+// composeMentions composes the members of the tiers to be mentioned. A member only stays in
+// the first tier it appears in, the author is excluded, each tier is sorted and capped to
+// limit(not positive means no limit). It reports whether any tier is capped.
+func composeMentions(author string, limit int, tiers ...*[]string) bool {
+	dedupMentions(author, tiers...)
+
+	return capMentions(limit, tiers...)
+}
+
+// composeMentions composes the members of the message and each sig in it, see composeMentions.
+// It runs after the first contacts are picked, so that they are picked from all the owners.
+// The owners which are not picked are not mentioned as the maintainers or committers either,
+// and they are listed without being capped.
+func (d *messageData) composeMentions(src *relationSource, limit int) {
+	dedupMentions(d.Author, &d.Owners, &d.OtherOwners, &d.Maintainers, &d.Committers)
+	d.More = capMentions(limit, &d.Owners, &d.Maintainers, &d.Committers)
+
+	for i := range d.Sigs {
+		s := &d.Sigs[i]
+		dedupMentions(d.Author, &s.Owners, &s.OtherOwners, &s.Maintainers, &s.Committers)
+		s.More = capMentions(limit, &s.Owners, &s.Maintainers, &s.Committers)
+		s.OwnersURL = src.ownersURL(s.Name)
+	}
+}
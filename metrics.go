@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	routeLabeled      = "labeled"
+	routeNoMatch      = "no_match"
+	routeDefaultOwner = "default_owner"
+)
+
+// giteeLatencyBuckets are the upper bounds in seconds of the buckets of the latency of gitee requests.
+var giteeLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// botMetrics are exposed at /metrics in the text format of prometheus.
+var botMetrics = newMetrics()
+
+type metrics struct {
+	registry *prometheus.Registry
+
+	events        *prometheus.CounterVec
+	eventErrors   *prometheus.CounterVec
+	routing       *prometheus.CounterVec
+	giteeLatency  *prometheus.HistogramVec
+	giteeFailures *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sigguide_events_total",
+			Help: "The number of the events handled by the robot.",
+		}, []string{"type", "action"}),
+		eventErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sigguide_event_errors_total",
+			Help: "The number of the events which failed to be handled.",
+		}, []string{"type", "action"}),
+		routing: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sigguide_routing_total",
+			Help: "The outcomes of routing the issues and pull requests to sigs, which are labeled, no_match and default_owner.",
+		}, []string{"item", "outcome"}),
+		giteeLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "sigguide_gitee_request_duration_seconds",
+			Help:    "The latency of the requests to gitee.",
+			Buckets: giteeLatencyBuckets,
+		}, []string{"method"}),
+		giteeFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sigguide_gitee_request_errors_total",
+			Help: "The number of the requests to gitee which failed.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		m.events, m.eventErrors, m.routing, m.giteeLatency, m.giteeFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// observeEvent counts the event and the error of handling it, and returns the error.
+func (m *metrics) observeEvent(typ, action string, err error) error {
+	m.events.WithLabelValues(typ, action).Inc()
+	if err != nil {
+		m.eventErrors.WithLabelValues(typ, action).Inc()
+	}
+
+	return err
+}
+
+// route counts the outcome of routing the item, which is issue or pull_request.
+func (m *metrics) route(item, outcome string) {
+	m.routing.WithLabelValues(item, outcome).Inc()
+}
+
+func (m *metrics) observeGitee(method string, start time.Time, err error) {
+	m.giteeLatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.giteeFailures.WithLabelValues(method).Inc()
+	}
+}
+
+// handler serves the metrics and the age of the relationship data in the cache.
+// It must be called only once.
+func (m *metrics) handler(cache *relationCache) http.Handler {
+	m.registry.MustRegister(newCacheAgeCollector(cache))
+
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// cacheAgeCollector collects the age of the relationship data in the cache when it is scraped.
+type cacheAgeCollector struct {
+	cache *relationCache
+	desc  *prometheus.Desc
+}
+
+func newCacheAgeCollector(cache *relationCache) *cacheAgeCollector {
+	return &cacheAgeCollector{
+		cache: cache,
+		desc: prometheus.NewDesc(
+			"sigguide_relationship_cache_age_seconds", "The age of the relationship data in the cache.",
+			[]string{"key"}, nil,
+		),
+	}
+}
+
+func (c *cacheAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *cacheAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	for k, v := range c.cache.ages() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, v.Seconds(), k)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+)
+
+const (
+	roleAnyone       = "anyone"
+	roleAuthor       = "author"
+	roleCollaborator = "collaborator"
+	roleSigMember    = "sig_member"
+	roleDefaultOwner = "default_owner"
+
+	noPermission = `Hi ***@%s***, sorry, the command can only be run by %s.`
+)
+
+var roleDescriptions = map[string]string{
+	roleAnyone:       "anyone",
+	roleAuthor:       "the author",
+	roleCollaborator: "the collaborators of the repository",
+	roleSigMember:    "the maintainers and committers of the SIGs",
+	roleDefaultOwner: "the default owners",
+}
+
+type commandPermission struct {
+	// Roles are who can run the /sig, /remove-sig and /unsig commands, which can be:
+	// anyone, author, collaborator, sig_member and default_owner.
+	// Default is all of them except anyone.
+	Roles []string `json:"roles,omitempty"`
+}
+
+func (p *commandPermission) setDefault() {
+	if len(p.Roles) == 0 {
+		p.Roles = []string{roleAuthor, roleCollaborator, roleSigMember, roleDefaultOwner}
+	}
+}
+
+func (p *commandPermission) validate() error {
+	for _, r := range p.Roles {
+		if _, ok := roleDescriptions[r]; !ok {
+			return fmt.Errorf("unknown role: %s", r)
+		}
+	}
+
+	return nil
+}
+
+func (p *commandPermission) has(role string) bool {
+	return isOneOf(role, p.Roles)
+}
+
+func (p *commandPermission) String() string {
+	v := make([]string, 0, len(p.Roles))
+	for _, r := range p.Roles {
+		v = append(v, roleDescriptions[r])
+	}
+
+	return strings.Join(v, ", ")
+}
+
+// canRunSigCommand reports whether the commenter can run the sig commands on the sigs of labels.
+func (bot *robot) canRunSigCommand(e *sdk.NoteEvent, bc *botConfig, sigs *SigYaml, labels []string) (bool, error) {
+	p := &bc.SigCommandPermission
+	commenter := e.GetCommenter()
+
+	if p.has(roleAnyone) {
+		return true, nil
+	}
+
+	if p.has(roleAuthor) {
+		author := e.GetIssueAuthor()
+		if e.IsPullRequest() {
+			author = e.GetPRAuthor()
+		}
+
+		if commenter == author {
+			return true, nil
+		}
+	}
+
+	if p.has(roleDefaultOwner) {
+		for _, d := range sigs.DefaultOwners {
+			if d.GiteeID == commenter {
+				return true, nil
+			}
+		}
+	}
+
+	org, repo := e.GetOrgRepo()
+
+	if p.has(roleSigMember) {
+		for i := range sigs.Sigs {
+			if !isOneOf(sigs.Sigs[i].SigLabel, labels) {
+				continue
+			}
+
+			maintainers, committers, err := bot.getMembers(bc, sigs.Sigs[i].Name, org, repo)
+			if err != nil {
+				return false, err
+			}
+
+			if maintainers.Has(commenter) || committers.Has(commenter) {
+				return true, nil
+			}
+		}
+	}
+
+	if p.has(roleCollaborator) {
+		return bot.cli.IsCollaborator(org, repo, commenter)
+	}
+
+	return false, nil
+}
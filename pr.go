@@ -4,183 +4,239 @@ import (
 	"fmt"
 	sdk "github.com/opensourceways/go-gitee/gitee"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"strconv"
 	"strings"
 )
 
-//func (bot *robot) dealPRNote(e *sdk.NoteEvent) error {
-//	comment := e.GetComment().GetBody()
-//	if !sigLabelRegex.MatchString(comment) {
-//		return nil
-//	}
-//
-//	org, repo := e.GetOrgRepo()
-//	number := e.GetPRNumber()
-//	author := e.GetPRAuthor()
-//
-//	sigNames := make(map[string]string, 0)
-//	sig := ""
-//	sigLabel := fmt.Sprintf("sig/%s", strings.Split(comment, " ")[1])
-//
-//	changes, err := bot.cli.GetPullRequestChanges(org, repo, number)
-//	if err != nil {
-//		return err
-//	}
-//
-//	deOwners := sets.NewString()
-//	sigs, err := bot.decodeSigsContent()
-//	if err != nil {
-//		return err
-//	}
-//
-//	for _, d := range sigs.DefaultOwners {
-//		deOwners.Insert(d.GiteeID)
-//	}
-//
-//	firstContactOwners := sets.NewString()
-//	repos := make(map[string][]RepoMember, 0)
-//	files := make(map[string][]FileMember, 0)
-//	for _, s := range sigs.Sigs {
-//		if s.SigLabel == sigLabel {
-//			sigNames[s.Name] = s.SigLink
-//			files[s.Name] = s.Files
-//			repos[s.Name] = s.Repos
-//			sig = s.Name
-//		}
-//	}
-//
-//	for _, c := range changes {
-//		for _, f := range files {
-//			for _, ff := range f {
-//				for _, fff := range ff.File {
-//					if c.Filename == fff {
-//						for _, o := range ff.Owner {
-//							firstContactOwners.Insert(o.GiteeID)
-//						}
-//					}
-//				}
-//			}
-//		}
-//	}
-//
-//	if len(firstContactOwners) == 0 {
-//		for _, r := range repos {
-//			for _, rp := range r {
-//				for _, rps := range rp.Repo {
-//					if repo == rps {
-//						for _, o := range rp.Owner {
-//							firstContactOwners.Insert(o.GiteeID)
-//						}
-//					}
-//				}
-//			}
-//		}
-//	}
-//
-//	if len(firstContactOwners) == 0 {
-//		firstContactOwners.Insert(deOwners.UnsortedList()...)
-//	}
-//
-//	maintainers := sets.NewString()
-//	committers := sets.NewString()
-//	ms, cs, err := bot.decodeOWNERSContent(sig)
-//	maintainers.Insert(ms...)
-//	committers.Insert(cs...)
-//
-//	// gen hyper link in messages
-//	sigsLinks := make([]string, 0)
-//	for k, v := range sigNames {
-//		sigsLinks = append(sigsLinks, fmt.Sprintf(sigLink, k, v))
-//	}
-//
-//	message := fmt.Sprintf(forPRReply, author, strings.Join(firstContactOwners.UnsortedList(), " , @"),
-//		strings.Join(maintainers.UnsortedList(), " , @"), strings.Join(committers.UnsortedList(), " , @"),
-//		strings.Join(sigsLinks, ""))
-//
-//	return bot.cli.CreatePRComment(org, repo, e.GetPRNumber(), message)
-//}
-
-func (bot *robot) genSpecialWelcomeMessage(bc *botConfig, org, repo, author, fileName string, labels sets.String) (string, error) {
-	owners := sets.NewString()
-	sigName := make(map[string]string, 0)
-	deOwners := sets.NewString()
-	diffHasSigLabel := false
-	for l := range labels {
-		if len(owners) > 0 {
-			break
+// dealPRNote labels the pull request by the /sig and /remove-sig commands. The guide is updated when
+// the label event comes, or at once if no label needs adding.
+func (bot *robot) dealPRNote(e *sdk.NoteEvent, bc *botConfig, sigLabels, rmLabels []string) error {
+	org, repo := e.GetOrgRepo()
+	number := e.GetPRNumber()
+
+	guided, done, err := bot.labelPRBySigCommand(bc, org, repo, number, sigLabels, rmLabels)
+	if err != nil || done {
+		return err
+	}
+
+	// edit the guide for the remaining sigs
+	if len(guided) == 0 {
+		return bot.clearPRGuide(org, repo, number)
+	}
+
+	return bot.postPRGuide(bc, org, repo, number, e.GetPRAuthor(), e.GetPullRequest().Body, guided)
+}
+
+// labelPRBySigCommand removes the sig labels of the /remove-sig command and adds the ones of the /sig command,
+// the other sig labels added by the robot are removed too when adding. The labels of the /sig command
+// are chosen by hand, so they are never removed by the robot later. It returns the sig labels which
+// the guide is for, and done is true if the guide needs no update at once.
+func (bot *robot) labelPRBySigCommand(bc *botConfig, org, repo string, number int32, sigLabels, rmLabels []string) (
+	guided sets.String, done bool, err error,
+) {
+	labels, err := bot.cli.GetPRLabels(org, repo, number)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := sets.NewString()
+	for _, l := range labels {
+		if strings.HasPrefix(l.Name, "sig/") {
+			current.Insert(l.Name)
 		}
+	}
 
-		if strings.HasPrefix(l, "sig/") {
-			diffHasSigLabel = true
-			fileOwner, defaultOwners, sig, link, err := bot.getFileOwner(l, fmt.Sprintf("%s/%s", repo, fileName), repo)
-			if err != nil {
-				return "", err
+	chosen := sets.NewString(sigLabels...)
+	removed := current.Intersection(sets.NewString(rmLabels...)).Difference(chosen)
+
+	if len(removed) > 0 || len(chosen) > 0 {
+		botLabels, err := bot.botAddedPRLabels(bc, org, repo, number)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(chosen) > 0 {
+			removed = removed.Union(current.Intersection(botLabels).Difference(chosen))
+		}
+
+		if len(removed) > 0 {
+			if err := bot.cli.RemovePRLabels(org, repo, number, removed.List()); err != nil {
+				return nil, false, err
 			}
+		}
 
-			owners.Insert(fileOwner.UnsortedList()...)
-			deOwners.Insert(defaultOwners.UnsortedList()...)
-			sigName[sig] = link
+		// the record is seeded by the labels of the robot, for the pull request labeled before they are recorded.
+		// The removed labels stay in the record, so that they are not added again on push.
+		err = bot.recordBotLabels(prKey(org, repo, number), current.Intersection(botLabels), chosen)
+		if err != nil {
+			return nil, false, err
 		}
 	}
 
-	if len(owners) == 0 {
-		owners.Insert(deOwners.UnsortedList()...)
+	if v := chosen.Difference(current); len(v) > 0 {
+		return nil, true, bot.cli.AddMultiPRLabel(org, repo, number, v.List())
 	}
 
-	maintainers := sets.NewString()
-	committers := sets.NewString()
-	for sn := range sigName {
-		if bc.CustomizeMembers {
-			os, cs, err := bot.decodeSpecialOWNERSContent(sn, org, repo)
-			if err != nil {
-				return "", err
-			}
+	guided = current.Difference(removed).Union(chosen)
+
+	return guided, len(guided) == 0 && len(removed) == 0, nil
+}
+
+// postPRGuide posts the guide of the sigs of labels to the pull request, or edits the previous one,
+// then assigns the pull request.
+func (bot *robot) postPRGuide(bc *botConfig, org, repo string, number int32, author, text string, labels sets.String) error {
+	changes, err := bot.cli.GetPullRequestChanges(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	var data *messageData
+	posted, err := bot.upsertPRGuide(org, repo, number, labels, func() (string, error) {
+		comment, d, err := bot.genSpecialWelcomeMessage(bc, org, repo, number, author, text, changes, labels)
+		data = d
+
+		return comment, err
+	})
+	if err != nil || !posted {
+		return err
+	}
+
+	for i := range data.Sigs {
+		if data.Sigs[i].defaultOwners {
+			botMetrics.route("pull_request", routeDefaultOwner)
+		}
+	}
 
-			maintainers.Insert(os...)
-			committers.Insert(cs...)
+	if err := bot.watchItem(org, repo, strconv.Itoa(int(number)), true, data); err != nil {
+		return err
+	}
+
+	return bot.assignPR(bc, org, repo, number, data)
+}
+
+// botAddedPRLabels returns the sig labels which the robot has added to the pull request, the others are
+// regarded as being added by hand. For the one labeled before the labels are recorded, they are the labels
+// which the robot may have added.
+func (bot *robot) botAddedPRLabels(bc *botConfig, org, repo string, number int32) (sets.String, error) {
+	if v, ok := bot.recordedBotLabels(prKey(org, repo, number)); ok {
+		return v, nil
+	}
+
+	return bot.genSigLabel(bc, org, repo, number)
+}
+
+// genSpecialWelcomeMessage generates one guide for all the sig labels,
+// which lists the owners of each sig next to the files that belong to it.
+// It returns the data of the guide too, in which the first contacts are picked.
+func (bot *robot) genSpecialWelcomeMessage(
+	bc *botConfig, org, repo string, number int32, author, text string,
+	changes []sdk.PullRequestFiles, labels sets.String,
+) (string, *messageData, error) {
+	sigGuides, err := bot.genSigGuides(bc, org, repo, changes, labels)
+	if err != nil || len(sigGuides) == 0 {
+		return "", nil, err
+	}
+
+	data := messageData{Author: author, Sigs: sigGuides, item: prKey(org, repo, number)}
+
+	owners, maintainers, committers := sets.NewString(), sets.NewString(), sets.NewString()
+	for i := range data.Sigs {
+		owners.Insert(data.Sigs[i].Owners...)
+		maintainers.Insert(data.Sigs[i].Maintainers...)
+		committers.Insert(data.Sigs[i].Committers...)
+	}
+	data.Owners = owners.List()
+	data.Maintainers = maintainers.List()
+	data.Committers = committers.List()
+
+	comment, err := bot.genMessage(bc, tmplPRGuide, text, &data)
+
+	return comment, &data, err
+}
+
+// genSigGuides finds the sigs of labels, and the owners of the changed files which belong to each of them.
+func (bot *robot) genSigGuides(bc *botConfig, org, repo string, changes []sdk.PullRequestFiles, labels sets.String) (
+	[]sigData, error,
+) {
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
+	if err != nil {
+		return nil, err
+	}
+
+	files := sigsOfFiles(sigs, repo, changes)
+
+	var r []sigData
+	for _, l := range labels.List() {
+		if !strings.HasPrefix(l, "sig/") {
 			continue
 		}
 
-		os, cs, err := bot.decodeOWNERSContent(sn)
+		fs := files[l]
+		if len(fs) == 0 {
+			// the sig may be chosen by hand, so take the files matched by any rule of it.
+			fs = filesOfSig(sigs, l, repo, changes)
+		}
+
+		g, err := bot.genSigGuide(bc, org, repo, l, fs)
+		if err != nil {
+			return nil, err
+		}
+
+		if g.Name != "" {
+			r = append(r, g)
+		}
+	}
+
+	return r, nil
+}
+
+// genSigGuide finds the owners of the files which belong to the sig of label.
+// The owners of the repository are used if no file is given.
+func (bot *robot) genSigGuide(bc *botConfig, org, repo, label string, files []string) (sigData, error) {
+	g := sigData{Label: label, Files: files}
+	owners := sets.NewString()
+	deOwners := sets.NewString()
+
+	fileNames := files
+	if len(fileNames) == 0 {
+		fileNames = []string{""}
+	}
+
+	for _, f := range fileNames {
+		fileOwner, defaultOwners, sig, link, err := bot.getFileOwner(bc, label, f, repo)
 		if err != nil {
-			return "", err
+			return g, err
 		}
 
-		maintainers.Insert(os...)
-		committers.Insert(cs...)
+		owners.Insert(fileOwner.UnsortedList()...)
+		deOwners.Insert(defaultOwners.UnsortedList()...)
+		g.Name = sig
+		g.Link = link
 	}
 
-	// remove duplicate
-	//for o := range owners {
-	//	for j := range maintainers {
-	//		if o == j {
-	//			maintainers.Delete(j)
-	//		}
-	//	}
-	//	for n := range committers {
-	//		if o == n {
-	//			committers.Delete(n)
-	//		}
-	//	}
-	//}
+	if g.Name == "" {
+		return g, nil
+	}
 
-	// gen hyper link in messages
-	sigsLinks := make([]string, 0)
-	for k, v := range sigName {
-		sigsLinks = append(sigsLinks, fmt.Sprintf(sigLink, k, v))
+	if len(owners) == 0 {
+		owners.Insert(deOwners.UnsortedList()...)
+		g.defaultOwners = true
 	}
+	g.Owners = owners.List()
 
-	if !diffHasSigLabel {
-		return "", nil
+	maintainers, committers, err := bot.getMembers(bc, g.Name, org, repo)
+	if err != nil {
+		return g, err
 	}
+	g.Maintainers = maintainers.List()
+	g.Committers = committers.List()
+	g.allMaintainers = g.Maintainers
 
-	return fmt.Sprintf(forPRReply, author, strings.Join(owners.UnsortedList(), " ,@"),
-		strings.Join(maintainers.UnsortedList(), " , @"),
-		strings.Join(committers.UnsortedList(), " , @"),
-		strings.Join(sigsLinks, "")), nil
+	return g, nil
 }
 
-func (bot *robot) getFileOwner(label, fileName, repo string) (sets.String, sets.String, string, string, error) {
-	sigs, err := bot.decodeSigsContent()
+func (bot *robot) getFileOwner(bc *botConfig, label, fileName, repo string) (sets.String, sets.String, string, string, error) {
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
@@ -203,16 +259,9 @@ func (bot *robot) getFileOwner(label, fileName, repo string) (sets.String, sets.
 	}
 
 	first := sets.NewString()
-	for _, s := range sig.Files {
-		if len(first) > 0 {
-			break
-		}
-		for _, ff := range s.File {
-			if ff == fileName {
-				for _, o := range s.Owner {
-					first.Insert(o.GiteeID)
-				}
-			}
+	if rule, _ := sig.matchFile(fileName); rule != nil {
+		for _, o := range rule.Owner {
+			first.Insert(o.GiteeID)
 		}
 	}
 
@@ -231,105 +280,149 @@ func (bot *robot) getFileOwner(label, fileName, repo string) (sets.String, sets.
 	return first, defaultOwners, sigName, link, nil
 }
 
-func (bot *robot) genSigLabel(org, repo string, number int32) (string, error) {
+// sigsOfFiles groups the changed files by the label of sig which they belong to.
+// The files are in the form of repo/path.
+func sigsOfFiles(sigs *SigYaml, repo string, changes []sdk.PullRequestFiles) map[string][]string {
+	r := make(map[string][]string)
+	for _, c := range changes {
+		f := fmt.Sprintf("%s/%s", repo, c.Filename)
+		if s := sigs.matchFile(f); s != nil {
+			r[s.SigLabel] = append(r[s.SigLabel], f)
+		}
+	}
+
+	return r
+}
+
+// filesOfSig returns the changed files which are matched by any rule of the sig of label.
+func filesOfSig(sigs *SigYaml, label, repo string, changes []sdk.PullRequestFiles) []string {
+	var r []string
+	for i := range sigs.Sigs {
+		s := &sigs.Sigs[i]
+		if s.SigLabel != label {
+			continue
+		}
+
+		for _, c := range changes {
+			f := fmt.Sprintf("%s/%s", repo, c.Filename)
+			if _, v := s.matchFile(f); v > 0 {
+				r = append(r, f)
+			}
+		}
+	}
+
+	return r
+}
+
+// genSigLabel returns the labels of all the sigs which the changed files of the pull request belong to.
+func (bot *robot) genSigLabel(bc *botConfig, org, repo string, number int32) (sets.String, error) {
 	changes, err := bot.cli.GetPullRequestChanges(org, repo, number)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	sigs, err := bot.decodeSigsContent()
+	return bot.sigLabelsOfChanges(bc, repo, changes)
+}
+
+// sigLabelsOfChanges returns the labels of all the sigs which the changed files belong to.
+// If no file belongs to any sig, the label of the sig which the repository belongs to is returned.
+func (bot *robot) sigLabelsOfChanges(bc *botConfig, repo string, changes []sdk.PullRequestFiles) (sets.String, error) {
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	sigLabel := ""
-	for _, c := range changes {
-		if sigLabel != "" {
-			break
-		}
-		for _, s := range sigs.Sigs {
-			for _, f := range s.Files {
-				for _, ff := range f.File {
-					if fmt.Sprintf("%s/%s", repo, c.Filename) == ff {
-						sigLabel = s.SigLabel
-					}
-				}
-			}
-		}
+	labels := sets.NewString()
+	for l := range sigsOfFiles(sigs, repo, changes) {
+		labels.Insert(l)
 	}
 
-	if sigLabel != "" {
-		return sigLabel, nil
+	if len(labels) > 0 {
+		return labels, nil
 	}
 
 	for _, s := range sigs.Sigs {
-		if sigLabel != "" {
+		if len(labels) > 0 {
 			break
 		}
 
 		for _, r := range s.Repos {
 			for _, rr := range r.Repo {
 				if repo == rr {
-					sigLabel = s.SigLabel
+					labels.Insert(s.SigLabel)
 				}
 			}
 		}
 	}
 
-	return sigLabel, nil
+	return labels, nil
 }
 
-func (bot *robot) dealPRPush(e *sdk.PullRequestEvent) error {
+// dealPRPush relabels the pull request when the sigs which the changed files belong to changed.
+// Only the sig labels added by the robot are replaced, the ones added or removed by hand stay put.
+func (bot *robot) dealPRPush(bc *botConfig, e *sdk.PullRequestEvent) error {
 	org, repo := e.GetOrgRepo()
-	num := e.GetPRNumber()
 
-	labels := e.GetPRLabelSet()
 	currentLabel := sets.NewString()
-	for l := range labels {
+	for l := range e.GetPRLabelSet() {
 		if strings.HasPrefix(l, "sig/") {
 			currentLabel.Insert(l)
 		}
 	}
 
+	return bot.relabelPR(bc, org, repo, e.GetPRNumber(), currentLabel)
+}
+
+// relabelPR replaces the sig labels added by the robot to the pull request, whose sig labels are
+// currentLabel, with the labels of the sigs of the changed files.
+func (bot *robot) relabelPR(bc *botConfig, org, repo string, num int32, currentLabel sets.String) error {
 	changes, err := bot.cli.GetPullRequestChanges(org, repo, num)
 	if err != nil {
 		return err
 	}
 
-	label := ""
-
-	sigs, err := bot.decodeSigsContent()
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
 	if err != nil {
 		return err
 	}
 
-	for _, s := range sigs.Sigs {
-		if label != "" {
-			break
-		}
+	labels := sets.NewString()
+	for l := range sigsOfFiles(sigs, repo, changes) {
+		labels.Insert(l)
+	}
 
-		for _, f := range s.Files {
-			for _, ff := range f.File {
-				for _, c := range changes {
-					if fmt.Sprintf("%s/%s", repo, c.Filename) == ff {
-						label = s.SigLabel
-						if _, ok := currentLabel[label]; ok {
-							label = ""
-						}
-					}
-				}
-			}
-		}
+	if len(labels) == 0 {
+		return nil
 	}
 
-	if label != "" {
-		err := bot.cli.RemovePRLabels(org, repo, num, currentLabel.UnsortedList())
-		if err != nil {
+	key := prKey(org, repo, num)
+
+	// the pull request labeled before the labels are recorded keeps all its labels.
+	botLabels, ok := bot.recordedBotLabels(key)
+	if !ok {
+		botLabels = currentLabel.Intersection(labels)
+	}
+
+	removed := currentLabel.Intersection(botLabels).Difference(labels)
+
+	// the labels of the robot which are not on the pull request any more are removed by hand.
+	added := labels.Difference(currentLabel).Difference(botLabels)
+
+	if len(removed) == 0 && len(added) == 0 {
+		return nil
+	}
+
+	if len(removed) > 0 {
+		if err := bot.cli.RemovePRLabels(org, repo, num, removed.List()); err != nil {
 			return err
 		}
+	}
 
-		return bot.cli.AddMultiPRLabel(org, repo, num, []string{label})
+	if len(added) > 0 {
+		if err := bot.cli.AddMultiPRLabel(org, repo, num, added.List()); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return bot.recordBotLabels(key, added, removed)
 }
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+// fakeClient is the pull request with the labels and the changed files,
+// the methods not overridden are never called.
+type fakeClient struct {
+	iClient
+
+	labels   []string
+	files    []string
+	added    []string
+	removed  []string
+	assigned []string
+}
+
+func (c *fakeClient) GetPRLabels(org, repo string, number int32) ([]sdk.Label, error) {
+	r := make([]sdk.Label, 0, len(c.labels))
+	for _, l := range c.labels {
+		r = append(r, sdk.Label{Name: l})
+	}
+
+	return r, nil
+}
+
+func (c *fakeClient) GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error) {
+	r := make([]sdk.PullRequestFiles, 0, len(c.files))
+	for _, f := range c.files {
+		r = append(r, sdk.PullRequestFiles{Filename: f})
+	}
+
+	return r, nil
+}
+
+func (c *fakeClient) AddMultiPRLabel(org, repo string, number int32, labels []string) error {
+	c.added = append(c.added, labels...)
+
+	return nil
+}
+
+func (c *fakeClient) RemovePRLabels(org, repo string, number int32, labels []string) error {
+	c.removed = append(c.removed, labels...)
+
+	return nil
+}
+
+func (c *fakeClient) AssignPR(org, repo string, number int32, logins []string) error {
+	c.assigned = append(c.assigned, logins...)
+
+	return nil
+}
+
+func (c *fakeClient) UnassignPR(org, repo string, number int32, logins []string) error {
+	return nil
+}
+
+var testSigs = SigYaml{Sigs: []Sig{
+	{Name: "storage", SigLabel: "sig/storage", Files: []FileMember{{File: []string{"r/src/storage/"}}}},
+	{Name: "sqlengine", SigLabel: "sig/sqlengine", Files: []FileMember{{File: []string{"r/src/sql/"}}}},
+	{Name: "tools", SigLabel: "sig/tools", Repos: []RepoMember{{Repo: []string{"tools"}}}},
+}}
+
+// newTestRobot returns the robot with the relationship of testSigs, and the sig labels recorded
+// for the pull request o/r!1 if recorded is not nil.
+func newTestRobot(t *testing.T, cli iClient, recorded []string) (*robot, *botConfig) {
+	b, err := yaml.Marshal(&testSigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &stateStore{}
+	if recorded != nil {
+		store.data.BotLabels = map[string][]string{prKey("o", "r", 1): recorded}
+	}
+
+	bot := &robot{
+		cli:   cli,
+		cache: newRelationCache(time.Hour),
+		store: store,
+		local: func(string) ([]byte, error) { return b, nil },
+	}
+
+	bc := &botConfig{}
+	bc.setDefault()
+
+	return bot, bc
+}
+
+func checkLabels(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+
+	if !sets.NewString(got...).Equal(sets.NewString(want...)) {
+		t.Errorf("%s: got %v, want %v", what, got, want)
+	}
+}
+
+func checkRecorded(t *testing.T, bot *robot, want ...string) {
+	t.Helper()
+
+	v, ok := bot.recordedBotLabels(prKey("o", "r", 1))
+	if !ok {
+		t.Fatal("the labels are not recorded")
+	}
+
+	checkLabels(t, "recorded", v.List(), want...)
+}
+
+func TestRelabelPR(t *testing.T) {
+	cases := []struct {
+		name     string
+		labels   []string
+		recorded []string
+		files    []string
+		rmLabels []string
+
+		added       []string
+		removed     []string
+		recordAfter []string
+	}{
+		{
+			name:        "no record",
+			files:       []string{"src/storage/a.c"},
+			added:       []string{"sig/storage"},
+			recordAfter: []string{"sig/storage"},
+		},
+		{
+			name:        "no record and the labels on it are kept",
+			labels:      []string{"sig/tools"},
+			files:       []string{"src/storage/a.c"},
+			added:       []string{"sig/storage"},
+			recordAfter: []string{"sig/storage"},
+		},
+		{
+			name:        "the label of the robot is replaced",
+			labels:      []string{"sig/storage"},
+			recorded:    []string{"sig/storage"},
+			files:       []string{"src/sql/a.c"},
+			added:       []string{"sig/sqlengine"},
+			removed:     []string{"sig/storage"},
+			recordAfter: []string{"sig/sqlengine"},
+		},
+		{
+			name:        "the label of the robot removed by hand does not come back",
+			recorded:    []string{"sig/storage"},
+			files:       []string{"src/storage/a.c", "src/sql/a.c"},
+			added:       []string{"sig/sqlengine"},
+			recordAfter: []string{"sig/storage", "sig/sqlengine"},
+		},
+		{
+			name:        "the label added by hand is kept",
+			labels:      []string{"sig/storage", "sig/tools"},
+			recorded:    []string{"sig/storage"},
+			files:       []string{"src/sql/a.c"},
+			added:       []string{"sig/sqlengine"},
+			removed:     []string{"sig/storage"},
+			recordAfter: []string{"sig/sqlengine"},
+		},
+		{
+			name:        "the label chosen by hand is not removed",
+			labels:      []string{"sig/storage"},
+			recorded:    []string{},
+			files:       []string{"src/sql/a.c"},
+			added:       []string{"sig/sqlengine"},
+			recordAfter: []string{"sig/sqlengine"},
+		},
+		{
+			name:        "the label removed by /remove-sig does not come back",
+			labels:      []string{"sig/storage", "sig/sqlengine"},
+			recorded:    []string{"sig/storage", "sig/sqlengine"},
+			files:       []string{"src/storage/a.c", "src/sql/a.c"},
+			rmLabels:    []string{"sig/storage"},
+			recordAfter: []string{"sig/storage", "sig/sqlengine"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cli := &fakeClient{labels: c.labels, files: c.files}
+			bot, bc := newTestRobot(t, cli, c.recorded)
+
+			if len(c.rmLabels) > 0 {
+				if _, _, err := bot.labelPRBySigCommand(bc, "o", "r", 1, nil, c.rmLabels); err != nil {
+					t.Fatal(err)
+				}
+
+				cli.labels = sets.NewString(cli.labels...).Delete(cli.removed...).List()
+				cli.added, cli.removed = nil, nil
+			}
+
+			if err := bot.relabelPR(bc, "o", "r", 1, sets.NewString(cli.labels...)); err != nil {
+				t.Fatal(err)
+			}
+
+			checkLabels(t, "added", cli.added, c.added...)
+			checkLabels(t, "removed", cli.removed, c.removed...)
+			checkRecorded(t, bot, c.recordAfter...)
+		})
+	}
+}
+
+func TestLabelPRBySigCommand(t *testing.T) {
+	cases := []struct {
+		name      string
+		labels    []string
+		recorded  []string
+		files     []string
+		sigLabels []string
+		rmLabels  []string
+
+		added       []string
+		removed     []string
+		recordAfter []string
+		guided      []string
+		done        bool
+	}{
+		{
+			name:        "the record is seeded when removing",
+			labels:      []string{"sig/storage", "sig/tools"},
+			files:       []string{"src/storage/a.c"},
+			rmLabels:    []string{"sig/tools"},
+			removed:     []string{"sig/tools"},
+			recordAfter: []string{"sig/storage"},
+			guided:      []string{"sig/storage"},
+		},
+		{
+			name:        "the record is seeded only by the labels on it",
+			labels:      []string{"sig/tools"},
+			files:       []string{"src/storage/a.c"},
+			sigLabels:   []string{"sig/sqlengine"},
+			added:       []string{"sig/sqlengine"},
+			recordAfter: []string{},
+			done:        true,
+		},
+		{
+			name:        "the labels of the robot are replaced by the chosen ones",
+			labels:      []string{"sig/storage", "sig/tools"},
+			recorded:    []string{"sig/storage"},
+			files:       []string{"src/storage/a.c"},
+			sigLabels:   []string{"sig/sqlengine"},
+			added:       []string{"sig/sqlengine"},
+			removed:     []string{"sig/storage"},
+			recordAfter: []string{"sig/storage"},
+			done:        true,
+		},
+		{
+			name:        "the labels chosen by hand are kept",
+			labels:      []string{"sig/sqlengine"},
+			recorded:    []string{},
+			files:       []string{"src/storage/a.c"},
+			sigLabels:   []string{"sig/storage"},
+			added:       []string{"sig/storage"},
+			recordAfter: []string{},
+			done:        true,
+		},
+		{
+			name:        "the chosen label on it only updates the guide",
+			labels:      []string{"sig/storage", "sig/sqlengine"},
+			recorded:    []string{"sig/storage"},
+			sigLabels:   []string{"sig/sqlengine"},
+			removed:     []string{"sig/storage"},
+			recordAfter: []string{"sig/storage"},
+			guided:      []string{"sig/sqlengine"},
+		},
+		{
+			name:        "all the labels are removed",
+			labels:      []string{"sig/storage"},
+			recorded:    []string{"sig/storage"},
+			rmLabels:    []string{"sig/storage"},
+			removed:     []string{"sig/storage"},
+			recordAfter: []string{"sig/storage"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cli := &fakeClient{labels: c.labels, files: c.files}
+			bot, bc := newTestRobot(t, cli, c.recorded)
+
+			guided, done, err := bot.labelPRBySigCommand(bc, "o", "r", 1, c.sigLabels, c.rmLabels)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkLabels(t, "added", cli.added, c.added...)
+			checkLabels(t, "removed", cli.removed, c.removed...)
+			checkRecorded(t, bot, c.recordAfter...)
+
+			if done != c.done {
+				t.Errorf("done: got %v, want %v", done, c.done)
+			}
+
+			if !done {
+				checkLabels(t, "guided", guided.List(), c.guided...)
+			}
+		})
+	}
+}
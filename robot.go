@@ -4,8 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"regexp"
-	"sigs.k8s.io/yaml"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,48 +17,67 @@ import (
 const botName = "sig-guide"
 
 const (
-	forIssueReply = `Hi ***@%s***, 
-if you want to get quick review about your issue, please contact the owner in first: @%s ,
-and then any of the maintainers: @%s
-and then any of the committers: @%s
-if you have any question, please contact the SIG: %s.`
-
-	forPRReply = `Hi ***@%s***, 
-if you want to get quick review about your pull request, please contact the owner in first: @%s ,
-and then any of the maintainers: @%s
-and then any of the committers: @%s
-if you have any question, please contact the SIG: %s.`
-
-	sigLink = `[%s](%s)`
-
-	notice = `Hi ***@%s***, please use the command ***/sig xxx*** to add a SIG label to this issue.
-For example: ***/sig sqlengine*** or ***/sig storageengine*** or ***/sig om*** or ***/sig ai*** and so on.
-You can find more SIG labels from [Here](https://opengauss.org/zh/member.html#sig).
-If you have no idea about that, please contact with @%s .`
+	issueStateClosed   = "closed"
+	issueStateRejected = "rejected"
+	prStateClosed      = "closed"
+	prStateMerged      = "merged"
 )
 
-var (
-	sigLabelRegex = regexp.MustCompile(`(?m)^/sig\s*(.*?)\s*$`)
+const (
+	malformedCommand = `Hi ***@%s***, the command can not be handled:
+- %s`
+
+	unknownSig = `Hi ***@%s***,
+- %s
+
+The valid SIG labels are: ` + "`%s`"
 )
 
 type iClient interface {
 	CreatePRComment(owner, repo string, number int32, comment string) error
+	UpdatePRComment(org, repo string, commentID int32, comment string) error
+	ListPRComments(org, repo string, number int32) ([]sdk.PullRequestComments, error)
 	CreateIssueComment(owner, repo string, number string, comment string) error
+	UpdateIssueComment(org, repo string, commentID int32, comment string) error
+	ListIssueComments(org, repo, number string) ([]sdk.Note, error)
 	GetBot() (sdk.User, error)
 	GetIssueLabels(org, repo, number string) ([]sdk.Label, error)
+	RemoveIssueLabel(org, repo, number, label string) error
 	GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error)
+	GetPRLabels(org, repo string, number int32) ([]sdk.Label, error)
 	AddMultiPRLabel(org, repo string, number int32, label []string) error
 	GetPathContent(org, repo, path, ref string) (sdk.Content, error)
 	AddMultiIssueLabel(org, repo, number string, label []string) error
 	RemovePRLabels(org, repo string, number int32, labels []string) error
+	AssignGiteeIssue(org, repo string, number string, login string) error
+	AssignPR(owner, repo string, number int32, logins []string) error
+	UnassignPR(owner, repo string, number int32, logins []string) error
+	IsCollaborator(owner, repo, login string) (bool, error)
+	GetIssue(org, repo, number string) (sdk.Issue, error)
+	GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error)
+	CreateIssue(org, repo, title, body string) (sdk.Issue, error)
+	CreateFile(org, repo, branch, path, content, commitMsg string) (sdk.CommitContent, error)
+	GetRepoLabels(owner, repo string) ([]sdk.Label, error)
+	CreateRepoLabel(org, repo, label, color string) error
+	UpdateRepoLabel(org, repo, oldLabel, name, color string) error
 }
 
-func newRobot(cli iClient) *robot {
-	return &robot{cli: cli}
+func newRobot(cli iClient, cacheTTL time.Duration, store *stateStore) *robot {
+	return &robot{cli: cli, cache: newRelationCache(cacheTTL), store: store}
 }
 
 type robot struct {
-	cli iClient
+	cli   iClient
+	cache *relationCache
+	store *stateStore
+	login botLogin
+
+	// local loads the relationship from a local checkout instead of gitee if it is set,
+	// which is used by the subcommands
+	local fileLoader
+
+	// labelSyncing is 1 when the labels are being synced
+	labelSyncing int32
 }
 
 func (bot *robot) NewConfig() config.Config {
@@ -79,12 +97,35 @@ func (bot *robot) getConfig(cfg config.Config, org, repo string) (*botConfig, er
 }
 
 func (bot *robot) RegisterEventHandler(p framework.HandlerRegitster) {
-	p.RegisterIssueHandler(bot.handleIssueEvent)
-	p.RegisterPullRequestHandler(bot.handlePREvent)
-	p.RegisterNoteEventHandler(bot.handleNoteEvent)
+	p.RegisterIssueHandler(func(e *sdk.IssueEvent, c config.Config, log *logrus.Entry) error {
+		return botMetrics.observeEvent("issue", e.GetAction(), bot.handleIssueEvent(e, c, log))
+	})
+
+	p.RegisterPullRequestHandler(func(e *sdk.PullRequestEvent, c config.Config, log *logrus.Entry) error {
+		return botMetrics.observeEvent("pull_request", sdk.GetPullRequestAction(e), bot.handlePREvent(e, c, log))
+	})
+
+	p.RegisterNoteEventHandler(func(e *sdk.NoteEvent, c config.Config, log *logrus.Entry) error {
+		action := "other"
+		if e.IsCreatingCommentEvent() {
+			action = "comment"
+		}
+
+		return botMetrics.observeEvent("note", action, bot.handleNoteEvent(e, c, log))
+	})
+
+	p.RegisterPushEventHandler(func(e *sdk.PushEvent, c config.Config, log *logrus.Entry) error {
+		return botMetrics.observeEvent("push", "push", bot.handlePushEvent(e, c, log))
+	})
 }
 
 func (bot *robot) handleIssueEvent(e *sdk.IssueEvent, c config.Config, log *logrus.Entry) error {
+	if state := e.GetIssue().State; state == issueStateClosed || state == issueStateRejected {
+		org, repo := e.GetOrgRepo()
+
+		return bot.releaseItem(issueKey(org, repo, e.GetIssueNumber()))
+	}
+
 	if e.GetAction() != sdk.ActionOpen {
 		return nil
 	}
@@ -92,93 +133,184 @@ func (bot *robot) handleIssueEvent(e *sdk.IssueEvent, c config.Config, log *logr
 	org, repo := e.GetOrgRepo()
 	author := e.GetIssueAuthor()
 	number := e.GetIssueNumber()
+	body := e.GetIssue().Body
 
-	bc, _ := bot.getConfig(c, org, repo)
+	// the issues opened by the robot, such as the digests, need no guide.
+	login, err := bot.getBotLogin()
+	if err != nil {
+		return err
+	}
 
-	if repo == "openGauss-server" {
-		_, _, _, _, deOwners, err := bot.genIssueSigLabel(repo)
-		if err != nil {
-			return err
+	if author == login {
+		return nil
+	}
+
+	bc, err := bot.getConfig(c, org, repo)
+	if err != nil {
+		return err
+	}
+
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
+	if err != nil {
+		return err
+	}
+
+	if !bc.ManualSig.needManual(org, repo, sigs) {
+		return bot.labelIssueByRepo(bc, org, repo, number, author, body)
+	}
+
+	message, err := bot.genSigNotice(bc, author, body)
+	if err != nil {
+		return err
+	}
+
+	if err := bot.cli.CreateIssueComment(org, repo, number, message); err != nil {
+		return err
+	}
+
+	if err := bot.watchItem(org, repo, number, false, nil); err != nil {
+		return err
+	}
+
+	t := bc.ManualSig.fallbackTimeout()
+	if t <= 0 {
+		return nil
+	}
+
+	// the fallback is done by the loop checking the watched items.
+	key := issueKey(org, repo, number)
+
+	return bot.store.update(func(d *stateData) {
+		if w := d.Watched[key]; w != nil {
+			w.FallbackAt = time.Now().Add(t)
 		}
-		return bot.cli.CreateIssueComment(org, repo, number, fmt.Sprintf(notice, e.GetIssueAuthor(),
-			strings.Join(deOwners.UnsortedList(), " , @")))
+	})
+}
+
+// fallbackIssueSig labels the issue by the sig of repository if nobody has chosen a sig for it.
+func (bot *robot) fallbackIssueSig(bc *botConfig, org, repo, number, author, body string) error {
+	labels, err := bot.cli.GetIssueLabels(org, repo, number)
+	if err != nil {
+		return err
 	}
 
-	label, sig, link, firstOwners, deOwners, err := bot.genIssueSigLabel(repo)
+	for _, l := range labels {
+		if strings.HasPrefix(l.Name, "sig/") {
+			return nil
+		}
+	}
+
+	return bot.labelIssueByRepo(bc, org, repo, number, author, body)
+}
+
+// labelIssueByRepo labels the issue by the sig which the repository belongs to, and posts the guide.
+func (bot *robot) labelIssueByRepo(bc *botConfig, org, repo, number, author, body string) error {
+	label, sig, link, firstOwners, deOwners, err := bot.genIssueSigLabel(bc, repo)
 	if err != nil {
 		return err
 	}
 
 	if label == "" || sig == "" || link == "" {
-		return nil
+		botMetrics.route("issue", routeNoMatch)
+
+		return bot.watchItem(org, repo, number, false, nil)
 	}
 
-	time.Sleep(600 * time.Millisecond)
 	err = bot.cli.AddMultiIssueLabel(org, repo, number, []string{label})
 	if err != nil {
 		return err
 	}
 
-	maintainers, committers := sets.NewString(), sets.NewString()
-	if bc.CustomizeMembers {
-		ms, cs, err := bot.decodeSpecialOWNERSContent(sig, org, repo)
-		if err != nil {
-			return err
-		}
-		maintainers.Insert(ms...)
-		committers.Insert(cs...)
-	} else {
-		ms, cs, err := bot.decodeOWNERSContent(sig)
-		if err != nil {
-			return err
-		}
-		maintainers.Insert(ms...)
-		committers.Insert(cs...)
+	if err := bot.recordBotLabels(issueKey(org, repo, number), sets.NewString(label), nil); err != nil {
+		return err
+	}
+
+	botMetrics.route("issue", routeLabeled)
+
+	maintainers, committers, err := bot.getMembers(bc, sig, org, repo)
+	if err != nil {
+		return err
 	}
 
 	if len(firstOwners) == 0 {
 		firstOwners.Insert(deOwners.UnsortedList()...)
+		botMetrics.route("issue", routeDefaultOwner)
+	}
+
+	data := &messageData{
+		item:        issueKey(org, repo, number),
+		Author:      author,
+		Owners:      firstOwners.List(),
+		Maintainers: maintainers.List(),
+		Committers:  committers.List(),
+		Sigs: []sigData{{
+			Name:        sig,
+			Label:       label,
+			Link:        link,
+			Owners:      firstOwners.List(),
+			Maintainers: maintainers.List(),
+			Committers:  committers.List(),
+		}},
+	}
+
+	posted, err := bot.upsertIssueGuide(org, repo, number, sets.NewString(label), func() (string, error) {
+		return bot.genMessage(bc, tmplIssueGuide, body, data)
+	})
+	if err != nil || !posted {
+		return err
 	}
 
-	//remove duplicate
-	//for f := range firstOwners {
-	//	for m := range maintainers {
-	//		if m == f {
-	//			maintainers.Delete(m)
-	//		}
-	//	}
-	//
-	//	for c := range committers {
-	//		if c == f {
-	//			committers.Delete(c)
-	//		}
-	//	}
-	//}
-
-	message := fmt.Sprintf(forIssueReply, author, strings.Join(firstOwners.UnsortedList(), " , @"),
-		strings.Join(maintainers.UnsortedList(), " , @"), strings.Join(committers.UnsortedList(), " , @"),
-		fmt.Sprintf(sigLink, sig, link))
-
-	return bot.cli.CreateIssueComment(org, repo, number, message)
+	if err := bot.watchItem(org, repo, number, false, data); err != nil {
+		return err
+	}
+
+	return bot.assignIssue(bc, org, repo, number, data)
 }
 
 func (bot *robot) handlePREvent(e *sdk.PullRequestEvent, c config.Config, log *logrus.Entry) error {
+	if state := e.GetPullRequest().State; state == prStateClosed || state == prStateMerged {
+		org, repo := e.GetOrgRepo()
+
+		return bot.releaseItem(prKey(org, repo, e.GetPRNumber()))
+	}
+
 	// when pr has been opened, add sig label to it.
 	if sdk.GetPullRequestAction(e) == sdk.ActionOpen {
 		org, repo := e.GetOrgRepo()
 		number := e.GetPRNumber()
-		label, err := bot.genSigLabel(org, repo, number)
-		if err != nil || label == "" {
+		bc, err := bot.getConfig(c, org, repo)
+		if err != nil {
 			return err
 		}
 
-		time.Sleep(700 * time.Millisecond)
+		labels, err := bot.genSigLabel(bc, org, repo, number)
+		if err != nil {
+			return err
+		}
+
+		if len(labels) == 0 {
+			botMetrics.route("pull_request", routeNoMatch)
+
+			return bot.watchItem(org, repo, strconv.Itoa(int(number)), true, nil)
+		}
 
-		return bot.cli.AddMultiPRLabel(org, repo, number, []string{label})
+		if err := bot.cli.AddMultiPRLabel(org, repo, number, labels.List()); err != nil {
+			return err
+		}
+
+		botMetrics.route("pull_request", routeLabeled)
+
+		return bot.recordBotLabels(prKey(org, repo, number), labels, nil)
 	}
 
 	if sdk.GetPullRequestAction(e) == sdk.PRActionChangedSourceBranch {
-		return bot.dealPRPush(e)
+		org, repo := e.GetOrgRepo()
+		bc, err := bot.getConfig(c, org, repo)
+		if err != nil {
+			return err
+		}
+
+		return bot.dealPRPush(bc, e)
 	}
 
 	// when pr's label has been changed
@@ -189,139 +321,257 @@ func (bot *robot) handlePREvent(e *sdk.PullRequestEvent, c config.Config, log *l
 	org, repo := e.GetOrgRepo()
 	author := e.GetPRAuthor()
 	number := e.GetPRNumber()
-	msgs := make([]string, 0)
 
-	bc, _ := bot.getConfig(c, org, repo)
+	bc, err := bot.getConfig(c, org, repo)
+	if err != nil {
+		return err
+	}
 
 	staleLabels := sets.NewString()
 	for _, label := range e.GetPullRequest().StaleLabels {
-		staleLabels.Insert(label.Name)
-	}
-	diffLabels := sets.NewString()
-	if v := e.GetPRLabelSet().Difference(staleLabels); len(v) > 0 {
-		for l := range v {
-			diffLabels.Insert(l)
+		if strings.HasPrefix(label.Name, "sig/") {
+			staleLabels.Insert(label.Name)
 		}
 	}
 
-	copyDiffLabels := diffLabels
-	for d := range copyDiffLabels {
-		if !strings.HasPrefix(d, "sig/") {
-			diffLabels.Delete(d)
+	sigLabels := sets.NewString()
+	for l := range e.GetPRLabelSet() {
+		if strings.HasPrefix(l, "sig/") {
+			sigLabels.Insert(l)
 		}
 	}
 
-	if len(diffLabels) == 0 {
+	if sigLabels.Equal(staleLabels) {
+		return nil
+	}
+
+	if len(sigLabels) == 0 {
+		return bot.clearPRGuide(org, repo, number)
+	}
+
+	return bot.postPRGuide(bc, org, repo, number, author, e.GetPullRequest().Body, sigLabels)
+}
+
+func (bot *robot) handleNoteEvent(e *sdk.NoteEvent, c config.Config, log *logrus.Entry) error {
+	if !e.IsCreatingCommentEvent() {
+		return nil
+	}
+
+	if !e.IsPullRequest() && !e.IsIssue() {
 		return nil
 	}
 
-	// get pr changed files
-	changes, err := bot.cli.GetPullRequestChanges(org, repo, number)
+	org, repo := e.GetOrgRepo()
+	bc, err := bot.getConfig(c, org, repo)
 	if err != nil {
 		return err
 	}
 
-	for _, f := range changes {
-		if len(msgs) > 0 {
-			break
-		}
-		msg, err := bot.genSpecialWelcomeMessage(bc, org, repo, author, f.Filename, diffLabels)
+	comment := e.GetComment().GetBody()
+	names, malformed, found := parseSigCommand(comment, cmdSig)
+	rmNames, rmMalformed, rmFound := parseSigCommand(comment, cmdRemoveSig, cmdUnsig)
+	if !found && !rmFound {
+		return nil
+	}
+
+	if malformed = append(malformed, rmMalformed...); len(malformed) > 0 {
+		return bot.createComment(e, fmt.Sprintf(
+			malformedCommand, e.GetCommenter(), strings.Join(malformed, "\n- "),
+		))
+	}
+
+	sigs, err := bot.decodeSigsContent(&bc.RelationSource)
+	if err != nil {
+		return err
+	}
+
+	labels, unknown := resolveSigLabels(sigs, names)
+	rmLabels, rmUnknown := resolveSigLabels(sigs, rmNames)
+	if unknown = append(unknown, rmUnknown...); len(unknown) > 0 {
+		err := bot.createComment(e, fmt.Sprintf(
+			unknownSig, e.GetCommenter(), strings.Join(unknown, "\n- "), strings.Join(sigs.labels(), "` , `"),
+		))
 		if err != nil {
 			return err
 		}
-
-		msgs = append(msgs, msg)
 	}
 
-	comment := strings.Join(msgs, "")
-	if len(comment) == 0 {
+	if len(labels) == 0 && len(rmLabels) == 0 {
 		return nil
 	}
 
-	return bot.cli.CreatePRComment(org, repo, e.GetPRNumber(), comment)
-}
+	ok, err := bot.canRunSigCommand(e, bc, sigs, append(labels, rmLabels...))
+	if err != nil {
+		return err
+	}
 
-func (bot *robot) handleNoteEvent(e *sdk.NoteEvent, c config.Config, log *logrus.Entry) error {
-	if !e.IsCreatingCommentEvent() {
-		return nil
+	if !ok {
+		return bot.createComment(e, fmt.Sprintf(noPermission, e.GetCommenter(), bc.SigCommandPermission.String()))
 	}
 
 	if e.IsPullRequest() {
-		return nil
+		return bot.dealPRNote(e, bc, labels, rmLabels)
 	}
 
-	if e.IsIssue() {
-		org, repo := e.GetOrgRepo()
-		bc, _ := bot.getConfig(c, org, repo)
-		err := bot.dealIssueNote(e, bc)
-		if err != nil {
-			return err
+	return bot.dealIssueNote(e, bc, labels, rmLabels)
+}
+
+// resolveSigLabels returns the labels of the sigs named by names,
+// and the descriptions of the unknown names.
+func resolveSigLabels(sigs *SigYaml, names []string) ([]string, []string) {
+	var labels, unknown []string
+	for _, n := range names {
+		if l := sigs.resolveLabel(n); l != "" {
+			labels = append(labels, l)
+			continue
+		}
+
+		s := fmt.Sprintf("the SIG `%s` does not exist", n)
+		if l := sigs.suggestLabel(n); l != "" {
+			s += fmt.Sprintf(", did you mean `%s`?", strings.TrimPrefix(l, "sig/"))
 		}
+		unknown = append(unknown, s)
 	}
 
-	return nil
+	return labels, unknown
 }
 
-func (bot *robot) decodeSigsContent() (*SigYaml, error) {
-	fileContent, err := bot.cli.GetPathContent("opengauss", "tc", "gauss_relationship.yaml", "master")
-	if err != nil {
-		return nil, err
+// createComment comments on the issue or pull request of the note event.
+func (bot *robot) createComment(e *sdk.NoteEvent, comment string) error {
+	org, repo := e.GetOrgRepo()
+	if e.IsPullRequest() {
+		return bot.cli.CreatePRComment(org, repo, e.GetPRNumber(), comment)
 	}
 
-	c, err := base64.StdEncoding.DecodeString(fileContent.Content)
-	if err != nil {
-		return nil, err
-	}
+	return bot.cli.CreateIssueComment(org, repo, e.GetIssueNumber(), comment)
+}
 
-	var sigs SigYaml
-	err = yaml.Unmarshal(c, &sigs)
-	if err != nil {
-		return nil, err
+// releaseItem removes the closed issue or pull request from the workload of owners.
+func (bot *robot) releaseItem(item string) error {
+	return bot.store.update(func(d *stateData) {
+		d.release(item)
+	})
+}
+
+// recordBotLabels records the sig labels added by the robot to the item, and the ones removed or chosen by hand.
+func (bot *robot) recordBotLabels(item string, added, removed sets.String) error {
+	return bot.store.update(func(d *stateData) {
+		d.recordBotLabels(item, added.UnsortedList(), removed.UnsortedList())
+	})
+}
+
+// recordedBotLabels returns the sig labels added by the robot to the item, and whether they are recorded.
+// The items labeled before the labels are recorded have no record.
+func (bot *robot) recordedBotLabels(item string) (sets.String, bool) {
+	var labels []string
+	var ok bool
+	bot.store.view(func(d *stateData) {
+		labels, ok = d.BotLabels[item]
+	})
+
+	return sets.NewString(labels...), ok
+}
+
+// handlePushEvent drops the cached relationship data once the repository storing it changed.
+func (bot *robot) handlePushEvent(e *sdk.PushEvent, c config.Config, log *logrus.Entry) error {
+	org, repo := e.GetOrgRepo()
+	bot.cache.invalidate(cachePrefix(org, repo))
+
+	if v, ok := c.(*configuration); ok && v != nil && v.isRelationChanged(org, repo, e.GetRef()) {
+		bot.syncLabelsInBackground(c)
 	}
 
-	return &sigs, nil
+	return nil
 }
 
-func (bot *robot) decodeOWNERSContent(sigName string) ([]string, []string, error) {
-	fileContent, err := bot.cli.GetPathContent("opengauss", "tc", fmt.Sprintf("sigs/%s/OWNERS", sigName), "master")
+// getMembers returns the maintainers and committers of the sig.
+func (bot *robot) getMembers(bc *botConfig, sigName, org, repo string) (sets.String, sets.String, error) {
+	var ms, cs []string
+	var err error
+	if bc.CustomizeMembers {
+		ms, cs, err = bot.decodeSpecialOWNERSContent(&bc.RelationSource, sigName, org, repo)
+	} else {
+		ms, cs, err = bot.decodeOWNERSContent(&bc.RelationSource, sigName)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
-	c, err := base64.StdEncoding.DecodeString(fileContent.Content)
-	if err != nil {
-		return nil, nil, err
+	return sets.NewString(ms...), sets.NewString(cs...), nil
+}
+
+// loader returns the loader of the files of the relationship, which loads them from the repository
+// on gitee unless the robot runs with a local checkout.
+func (bot *robot) loader(src *relationSource) fileLoader {
+	if bot.local != nil {
+		return bot.local
 	}
 
-	var o OWNERS
-	err = yaml.Unmarshal(c, &o)
-	if err != nil {
-		return nil, nil, err
+	return func(path string) ([]byte, error) {
+		return bot.getFileContent(src, path)
 	}
+}
 
-	owner := o.Maintainers
-	committer := o.Committers
+func (bot *robot) getFileContent(src *relationSource, path string) ([]byte, error) {
+	fileContent, err := bot.cli.GetPathContent(src.Org, src.Repo, path, src.Ref)
+	if err != nil {
+		return nil, err
+	}
 
-	return owner, committer, nil
+	return base64.StdEncoding.DecodeString(fileContent.Content)
 }
 
-func (bot *robot) decodeSpecialOWNERSContent(sigName, org, repo string) ([]string, []string, error) {
-	fileContent, err := bot.cli.GetPathContent("opengauss", "tc", fmt.Sprintf("sigs/%s/OWNERS", sigName), "master")
+func (bot *robot) decodeSigsContent(src *relationSource) (*SigYaml, error) {
+	v, err := bot.cache.get(src.cacheKey("sigs", src.Path), func() (interface{}, error) {
+		var sigs SigYaml
+		if err := loadYaml(bot.loader(src), src.Path, &sigs); err != nil {
+			return nil, err
+		}
+
+		return &sigs, nil
+	})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	c, err := base64.StdEncoding.DecodeString(fileContent.Content)
+	return v.(*SigYaml), nil
+}
+
+func (bot *robot) decodeOWNERSContent(src *relationSource, sigName string) ([]string, []string, error) {
+	path := src.ownersPath(sigName)
+	v, err := bot.cache.get(src.cacheKey("owners", path), func() (interface{}, error) {
+		var o OWNERS
+		if err := loadYaml(bot.loader(src), path, &o); err != nil {
+			return nil, err
+		}
+
+		return &o, nil
+	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var o SpecialOWNERS
-	err = yaml.Unmarshal(c, &o)
+	o := v.(*OWNERS)
+
+	return o.Maintainers, o.Committers, nil
+}
+
+func (bot *robot) decodeSpecialOWNERSContent(src *relationSource, sigName, org, repo string) ([]string, []string, error) {
+	path := src.ownersPath(sigName)
+	v, err := bot.cache.get(src.cacheKey("special-owners", path), func() (interface{}, error) {
+		var o SpecialOWNERS
+		if err := loadYaml(bot.loader(src), path, &o); err != nil {
+			return nil, err
+		}
+
+		return &o, nil
+	})
 	if err != nil {
 		return nil, nil, err
 	}
 
+	o := v.(*SpecialOWNERS)
+
 	var owner []string
 	var committer []string
 	p := fmt.Sprintf("%s/%s", org, repo)
@@ -343,3 +593,11 @@ func (bot *robot) decodeSpecialOWNERSContent(sigName, org, repo string) ([]strin
 
 	return owner, committer, nil
 }
+
+func issueKey(org, repo, number string) string {
+	return fmt.Sprintf("%s/%s#%s", org, repo, number)
+}
+
+func prKey(org, repo string, number int32) string {
+	return fmt.Sprintf("%s/%s!%d", org, repo, number)
+}
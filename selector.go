@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	strategyAll         = "all"
+	strategyRoundRobin  = "round_robin"
+	strategyRandom      = "random"
+	strategyLeastLoaded = "least_loaded"
+)
+
+var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+type firstContactRule struct {
+	// Strategy is how to pick the first contacts from the owners, which can be:
+	// all, round_robin, random and least_loaded. least_loaded picks the owners with the
+	// fewest open issues and pull requests routed to them by the robot. Default is all
+	Strategy string `json:"strategy,omitempty"`
+
+	// Count is the number of the first contacts, default is 1
+	Count int `json:"count,omitempty"`
+}
+
+func (r *firstContactRule) validate() error {
+	switch r.Strategy {
+	case "", strategyAll, strategyRoundRobin, strategyRandom, strategyLeastLoaded:
+	default:
+		return fmt.Errorf("unknown strategy of first contact: %s", r.Strategy)
+	}
+
+	if r.Count < 0 {
+		return fmt.Errorf("count of first contact must not be negative")
+	}
+
+	return nil
+}
+
+type firstContactConfig struct {
+	firstContactRule
+
+	// Sigs overrides the rule for each sig, the key is the sig name
+	Sigs map[string]firstContactRule `json:"sigs,omitempty"`
+}
+
+func (c *firstContactConfig) setDefault() {
+	if c.Strategy == "" {
+		c.Strategy = strategyAll
+	}
+
+	if c.Count == 0 {
+		c.Count = 1
+	}
+}
+
+func (c *firstContactConfig) validate() error {
+	if err := c.firstContactRule.validate(); err != nil {
+		return err
+	}
+
+	for _, v := range c.Sigs {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *firstContactConfig) ruleFor(sigName string) firstContactRule {
+	r := c.firstContactRule
+	if v, ok := c.Sigs[sigName]; ok {
+		if v.Strategy != "" {
+			r.Strategy = v.Strategy
+		}
+
+		if v.Count > 0 {
+			r.Count = v.Count
+		}
+	}
+
+	return r
+}
+
+// firstContactPick is the first contacts picked for a sig of the item. It is recorded after the guide is posted,
+// so that a guide failing to be posted doesn't move the workload and the round robin.
+type firstContactPick struct {
+	sigKey   string
+	selected []string
+
+	// next is the position of the next first contact of the sig if roundRobin is true
+	next       int
+	roundRobin bool
+}
+
+// selectFirstContacts picks the first contacts from the owners of the sig whose key is sigKey,
+// and returns them and the other owners. The pick is nil if all the owners are the first contacts.
+func (bot *robot) selectFirstContacts(rule firstContactRule, sigKey string, owners []string) (
+	[]string, []string, *firstContactPick,
+) {
+	if rule.Strategy == strategyAll || len(owners) <= rule.Count {
+		return owners, nil, nil
+	}
+
+	candidates := append([]string{}, owners...)
+	pick := &firstContactPick{sigKey: sigKey}
+
+	bot.store.view(func(d *stateData) {
+		switch rule.Strategy {
+		case strategyRandom:
+			random.Shuffle(len(candidates), func(i, j int) {
+				candidates[i], candidates[j] = candidates[j], candidates[i]
+			})
+
+		case strategyRoundRobin:
+			n := d.RoundRobin[sigKey] % len(candidates)
+			candidates = append(candidates[n:], candidates[:n]...)
+			pick.next = (n + rule.Count) % len(candidates)
+			pick.roundRobin = true
+
+		case strategyLeastLoaded:
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return d.workload(candidates[i]) < d.workload(candidates[j])
+			})
+		}
+	})
+
+	selected := candidates[:rule.Count]
+	others := append([]string{}, candidates[rule.Count:]...)
+	sort.Strings(selected)
+	sort.Strings(others)
+	pick.selected = selected
+
+	return selected, others, pick
+}
+
+// pickFirstContacts picks the first contacts of each sig in the message from all its owners except the author,
+// the other owners are listed without being mentioned. The picks are kept in the message, see recordFirstContacts.
+func (d *messageData) pickFirstContacts(bot *robot, bc *botConfig) {
+	if len(d.Sigs) == 0 || d.item == "" {
+		return
+	}
+
+	d.picks = nil
+
+	var owners, others []string
+	for i := range d.Sigs {
+		s := &d.Sigs[i]
+		dedupMentions(d.Author, &s.Owners)
+
+		// the sigs of different relation sources may have the same name
+		key := bc.RelationSource.cacheKey("round_robin", s.Name)
+
+		selected, rest, pick := bot.selectFirstContacts(bc.FirstContact.ruleFor(s.Name), key, s.Owners)
+		if pick != nil {
+			d.picks = append(d.picks, *pick)
+		}
+
+		s.Owners, s.OtherOwners = selected, rest
+		owners = append(owners, selected...)
+		others = append(others, rest...)
+	}
+
+	d.Owners = owners
+	d.OtherOwners = others
+}
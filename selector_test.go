@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestGenMessagePicksFirstContactsBeforeMentionLimit(t *testing.T) {
+	var owners []string
+	for i := 0; i < 10; i++ {
+		owners = append(owners, fmt.Sprintf("owner%d", i))
+	}
+
+	for _, strategy := range []string{strategyRoundRobin, strategyRandom, strategyLeastLoaded} {
+		t.Run(strategy, func(t *testing.T) {
+			bot := &robot{cache: newRelationCache(time.Hour), store: &stateStore{}}
+			bc := &botConfig{
+				MentionLimit: 3,
+				FirstContact: firstContactConfig{firstContactRule: firstContactRule{Strategy: strategy}},
+			}
+			bc.setDefault()
+
+			picked := sets.NewString()
+			for i := 0; i < 200 && picked.Len() < len(owners); i++ {
+				data := &messageData{
+					Author: "author",
+					Owners: owners,
+					Sigs:   []sigData{{Name: "sig-a", Label: "sig/a", Owners: owners}},
+					item:   fmt.Sprintf("o/r#%d", i),
+				}
+
+				if _, err := bot.genMessage(bc, tmplIssueGuide, "", data); err != nil {
+					t.Fatal(err)
+				}
+
+				// as the guide is posted
+				bot.store.update(func(d *stateData) { d.recordFirstContacts(data.item, data.picks) })
+
+				s := &data.Sigs[0]
+				if len(s.Owners) != 1 || len(s.OtherOwners) != len(owners)-1 {
+					t.Fatalf("picked %v, others %v", s.Owners, s.OtherOwners)
+				}
+
+				if all := sets.NewString(s.Owners...).Insert(s.OtherOwners...); !all.Equal(sets.NewString(owners...)) {
+					t.Fatalf("owners are lost: %v", all.List())
+				}
+
+				picked.Insert(s.Owners...)
+			}
+
+			if picked.Len() != len(owners) {
+				t.Errorf("only %v are picked", picked.List())
+			}
+		})
+	}
+}
+
+func TestGenMessageCapsMentions(t *testing.T) {
+	bot := &robot{cache: newRelationCache(time.Hour), store: &stateStore{}}
+	bc := &botConfig{MentionLimit: 2}
+	bc.setDefault()
+
+	data := &messageData{
+		Author:      "author",
+		Owners:      []string{"o3", "o1", "o2", "author"},
+		Maintainers: []string{"o1", "m1"},
+		Sigs:        []sigData{{Name: "sig-a", Label: "sig/a", Owners: []string{"o3", "o1", "o2", "author"}}},
+		item:        "o/r#1",
+	}
+
+	if _, err := bot.genMessage(bc, tmplIssueGuide, "", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := data.Sigs[0].Owners; !sets.NewString(v...).Equal(sets.NewString("o1", "o2")) || !data.Sigs[0].More {
+		t.Errorf("owners of sig: %v, more: %v", v, data.Sigs[0].More)
+	}
+
+	if v := data.Maintainers; len(v) != 1 || v[0] != "m1" {
+		t.Errorf("maintainers: %v", v)
+	}
+}
+
+func TestRecordFirstContactsReplacesThePreviousOnes(t *testing.T) {
+	bot := &robot{cache: newRelationCache(time.Hour), store: &stateStore{}}
+	bc := &botConfig{FirstContact: firstContactConfig{firstContactRule: firstContactRule{Strategy: strategyLeastLoaded}}}
+	bc.setDefault()
+
+	guide := func(owners ...string) {
+		data := &messageData{
+			Author: "author",
+			Sigs:   []sigData{{Name: "sig-a", Label: "sig/a", Owners: owners}},
+			item:   "o/r#1",
+		}
+
+		if _, err := bot.genMessage(bc, tmplIssueGuide, "", data); err != nil {
+			t.Fatal(err)
+		}
+
+		bot.store.update(func(d *stateData) { d.recordFirstContacts(data.item, data.picks) })
+	}
+
+	// the guide failing to be posted is not recorded.
+	data := &messageData{Sigs: []sigData{{Name: "sig-a", Label: "sig/a", Owners: []string{"a1", "a2"}}}, item: "o/r#1"}
+	if _, err := bot.genMessage(bc, tmplIssueGuide, "", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := bot.store.data.Assigned; len(v) != 0 {
+		t.Fatalf("assigned before the guide is posted: %v", v)
+	}
+
+	guide("a1", "a2")
+	guide("b1", "b2")
+
+	workload := 0
+	for owner, items := range bot.store.data.Assigned {
+		if owner == "a1" || owner == "a2" {
+			t.Errorf("the owner %s of the previous guide still has %v", owner, items)
+		}
+
+		workload += len(items)
+	}
+
+	if workload != 1 {
+		t.Errorf("assigned: %v", bot.store.data.Assigned)
+	}
+}
@@ -1,5 +1,7 @@
 package main
 
+import "strings"
+
 type SigYaml struct {
 	Sigs          []Sig    `json:"sigs,omitempty"`
 	DefaultOwners []Member `json:"default_owners,omitempty"`
@@ -11,9 +13,13 @@ type Sig struct {
 	SigLink  string       `json:"sig_link,omitempty"`
 	Files    []FileMember `json:"files,omitempty"`
 	Repos    []RepoMember `json:"repos,omitempty"`
+
+	// Aliases are the other names can be used in the /sig command, e.g. sql for sig/sqlengine
+	Aliases []string `json:"aliases,omitempty"`
 }
 
 type FileMember struct {
+	// File is a list of patterns in the form of repo/path, see matchFile for the syntax
 	File  []string `json:"file,omitempty"`
 	Owner []Member `json:"owner,omitempty"`
 }
@@ -46,3 +52,163 @@ type SpecialRepoMember struct {
 	Maintainers []string `json:"maintainers,omitempty"`
 	Committers  []string `json:"committers,omitempty"`
 }
+
+// matchFile returns the rule of the sig which matches the file best and the score of it,
+// see matchFile for the precedence of rules.
+func (s *Sig) matchFile(file string) (*FileMember, int) {
+	if file == "" {
+		return nil, 0
+	}
+
+	var rule *FileMember
+	best := 0
+	for i := range s.Files {
+		for _, f := range s.Files[i].File {
+			if v := matchFile(f, file); v > best {
+				rule = &s.Files[i]
+				best = v
+			}
+		}
+	}
+
+	return rule, best
+}
+
+// matchFile returns the sig which has the best rule matching the file.
+func (s *SigYaml) matchFile(file string) *Sig {
+	var sig *Sig
+	best := 0
+	for i := range s.Sigs {
+		if _, v := s.Sigs[i].matchFile(file); v > best {
+			sig = &s.Sigs[i]
+			best = v
+		}
+	}
+
+	return sig
+}
+
+// hasRepo reports whether the repository belongs to the sig.
+func (s *Sig) hasRepo(repo string) bool {
+	for _, r := range s.Repos {
+		for _, rp := range r.Repo {
+			if rp == repo {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasLabel reports whether there is a sig of the label.
+func (s *SigYaml) hasLabel(label string) bool {
+	for i := range s.Sigs {
+		if s.Sigs[i].SigLabel == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveLabel returns the label of the sig which is named name by its label, name or aliases,
+// regardless of the case.
+func (s *SigYaml) resolveLabel(name string) string {
+	name = trimSigPrefix(name)
+	for i := range s.Sigs {
+		sig := &s.Sigs[i]
+		if strings.EqualFold(sig.SigLabel, "sig/"+name) || strings.EqualFold(sig.Name, name) {
+			return sig.SigLabel
+		}
+	}
+
+	for i := range s.Sigs {
+		for _, a := range s.Sigs[i].Aliases {
+			if strings.EqualFold(trimSigPrefix(a), name) {
+				return s.Sigs[i].SigLabel
+			}
+		}
+	}
+
+	return ""
+}
+
+// trimSigPrefix removes the prefix sig/ of the name regardless of the case.
+func trimSigPrefix(name string) string {
+	if len(name) >= 4 && strings.EqualFold(name[:4], "sig/") {
+		return name[4:]
+	}
+
+	return name
+}
+
+// labels returns all the labels of sigs.
+func (s *SigYaml) labels() []string {
+	r := make([]string, 0, len(s.Sigs))
+	for i := range s.Sigs {
+		if s.Sigs[i].SigLabel != "" {
+			r = append(r, s.Sigs[i].SigLabel)
+		}
+	}
+
+	return r
+}
+
+// suggestLabel returns the label of the sig whose label, name or aliases is the most similar
+// to the name, or empty if none is similar enough.
+func (s *SigYaml) suggestLabel(name string) string {
+	label := ""
+	best := len(name)/3 + 2
+	for i := range s.Sigs {
+		sig := &s.Sigs[i]
+		candidates := append([]string{strings.TrimPrefix(sig.SigLabel, "sig/"), sig.Name}, sig.Aliases...)
+		for _, c := range candidates {
+			d := editDistance(strings.ToLower(name), strings.ToLower(c))
+			if len(name) >= 3 && strings.HasPrefix(strings.ToLower(c), strings.ToLower(name)) {
+				// a prefix is as similar as one typo
+				d = minInt(d, 1)
+			}
+
+			if d < best {
+				best = d
+				label = sig.SigLabel
+			}
+		}
+	}
+
+	return label
+}
+
+// editDistance returns the levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
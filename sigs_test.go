@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestResolveLabel(t *testing.T) {
+	sigs := &SigYaml{Sigs: []Sig{
+		{Name: "SQLEngine", SigLabel: "sig/sqlengine"},
+		{Name: "Storage", SigLabel: "sig/storage", Aliases: []string{"sig/Store", "disk"}},
+	}}
+
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"sqlengine", "sig/sqlengine"},
+		{"SQLEngine", "sig/sqlengine"},
+		{"sig/sqlengine", "sig/sqlengine"},
+		{"SIG/SqlEngine", "sig/sqlengine"},
+		{"storage", "sig/storage"},
+		{"store", "sig/storage"},
+		{"Sig/STORE", "sig/storage"},
+		{"DISK", "sig/storage"},
+		{"tools", ""},
+	}
+
+	for _, c := range cases {
+		if got := sigs.resolveLabel(c.name); got != c.want {
+			t.Errorf("resolveLabel(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
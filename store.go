@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// stateStore keeps the state of the robot which should survive restarts in a json file.
+// The state is only kept in memory if the path is empty.
+type stateStore struct {
+	path string
+
+	lock sync.Mutex
+	data stateData
+}
+
+type stateData struct {
+	// RoundRobin is the position of the next first contact of each sig, the key is the relation source and the sig name
+	RoundRobin map[string]int `json:"round_robin,omitempty"`
+
+	// Assigned are the open issues and pull requests routed to each owner
+	Assigned map[string][]string `json:"assigned,omitempty"`
+
+	// Watched are the open issues and pull requests waiting for the response of owners, the key is the item
+	Watched map[string]*watchedItem `json:"watched,omitempty"`
+
+	// BotLabels are the sig labels added by the robot to each open issue and pull request,
+	// the other sig labels on them are regarded as being chosen by hand
+	BotLabels map[string][]string `json:"bot_labels,omitempty"`
+
+	// BotAssignees are the members assigned by the robot to each open pull request,
+	// the other assignees are never unassigned by the robot
+	BotAssignees map[string][]string `json:"bot_assignees,omitempty"`
+
+	// LastDigests are when the digest of each sig is published last time, the key is the relation source and the sig name
+	LastDigests map[string]time.Time `json:"last_digests,omitempty"`
+}
+
+func newStateStore(path string) (*stateStore, error) {
+	s := &stateStore{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &s.data); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// view calls f with the state, f must not change it.
+func (s *stateStore) view(f func(d *stateData)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	f(&s.data)
+}
+
+// update calls f to change the state and saves it.
+func (s *stateStore) update(f func(d *stateData)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	f(&s.data)
+
+	return s.save()
+}
+
+func (s *stateStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(&s.data)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (d *stateData) assign(owner, item string) {
+	if d.Assigned == nil {
+		d.Assigned = make(map[string][]string)
+	}
+
+	if !isOneOf(item, d.Assigned[owner]) {
+		d.Assigned[owner] = append(d.Assigned[owner], item)
+	}
+}
+
+// release removes the item from all the owners and stops watching it, it is called when the item is closed.
+func (d *stateData) release(item string) {
+	delete(d.Watched, item)
+	delete(d.BotLabels, item)
+	delete(d.BotAssignees, item)
+
+	d.unassign(item)
+}
+
+// recordFirstContacts replaces the owners which the item is routed to with the first contacts picked
+// for it, and moves the round robin of their sigs forward.
+func (d *stateData) recordFirstContacts(item string, picks []firstContactPick) {
+	d.unassign(item)
+
+	for i := range picks {
+		p := &picks[i]
+
+		if p.roundRobin {
+			if d.RoundRobin == nil {
+				d.RoundRobin = make(map[string]int)
+			}
+
+			d.RoundRobin[p.sigKey] = p.next
+		}
+
+		for _, o := range p.selected {
+			d.assign(o, item)
+		}
+	}
+}
+
+// unassign removes the item from all the owners.
+func (d *stateData) unassign(item string) {
+	for owner, items := range d.Assigned {
+		r := items[:0]
+		for _, v := range items {
+			if v != item {
+				r = append(r, v)
+			}
+		}
+
+		if len(r) == 0 {
+			delete(d.Assigned, owner)
+		} else {
+			d.Assigned[owner] = r
+		}
+	}
+}
+
+// recordBotLabels adds the labels added by the robot to the item, and removes the ones which are removed
+// or chosen by hand. The item is recorded even if no label is left, which means none of its labels is the robot's.
+func (d *stateData) recordBotLabels(item string, added, removed []string) {
+	if d.BotLabels == nil {
+		d.BotLabels = make(map[string][]string)
+	}
+
+	d.BotLabels[item] = sets.NewString(d.BotLabels[item]...).Insert(added...).Delete(removed...).List()
+}
+
+func (d *stateData) workload(owner string) int {
+	return len(d.Assigned[owner])
+}
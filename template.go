@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const (
+	langEN   = "en"
+	langZH   = "zh"
+	langAuto = "auto"
+
+	tmplIssueGuide = "issue_guide"
+	tmplPRGuide    = "pr_guide"
+	tmplSigNotice  = "sig_notice"
+	tmplReminder   = "reminder"
+
+	maxSigExamples = 4
+)
+
+// builtinTemplates are used when no template is configured.
+var builtinTemplates = map[string]templateSet{
+	langEN: {
+		IssueGuide: `Hi ***@{{.Author}}***,
+if you want to get quick review about your issue, please contact the owner in first: {{mention .Owners}}{{if .OtherOwners}} (or {{join .OtherOwners}}){{end}} ,
+and then any of the maintainers: {{mention .Maintainers}}
+and then any of the committers: {{mention .Committers}}
+{{if .More}}more members can be found in{{range .Sigs}} [OWNERS of {{.Name}}]({{.OwnersURL}}){{end}}
+{{end}}if you have any question, please contact the SIG: {{range .Sigs}}[{{.Name}}]({{.Link}}){{end}}.`,
+
+		PRGuide: `Hi ***@{{.Author}}***,
+if you want to get quick review about your pull request, please contact the SIGs below.
+{{range .Sigs}}SIG [{{.Name}}]({{.Link}}){{if .Files}} for the files: {{code .Files}}{{end}}:
+please contact the owner in first: {{mention .Owners}}{{if .OtherOwners}} (or {{join .OtherOwners}}){{end}} ,
+and then any of the maintainers: {{mention .Maintainers}}
+and then any of the committers: {{mention .Committers}}
+{{if .More}}more members can be found in [OWNERS]({{.OwnersURL}})
+{{end}}{{end}}`,
+
+		SigNotice: `Hi ***@{{.Author}}***, please use the command ***/sig xxx*** to add a SIG label to this issue.
+For example: {{range $i, $s := .Examples}}{{if $i}} or {{end}}***/sig {{$s}}***{{end}} and so on.
+{{if .SigsURL}}You can find more SIG labels from [Here]({{.SigsURL}}).
+{{end}}If you have no idea about that, please contact with {{mention .Owners}} .`,
+
+		Reminder: `Hi {{mention .Owners}},
+this has been waiting for a response from the SIG{{range .Sigs}} [{{.Name}}]({{.Link}}){{end}} for {{.Days}} days, could you please take a look?`,
+	},
+
+	langZH: {
+		IssueGuide: `***@{{.Author}}*** 你好，
+如果你希望 issue 能被快速处理，请先联系 owner：{{mention .Owners}}{{if .OtherOwners}}（或 {{join .OtherOwners}}）{{end}} ，
+然后可以联系任意一位 maintainer：{{mention .Maintainers}}
+或者任意一位 committer：{{mention .Committers}}
+{{if .More}}更多成员请查看{{range .Sigs}} [{{.Name}} 的 OWNERS]({{.OwnersURL}}){{end}}
+{{end}}如有疑问，请联系 SIG：{{range .Sigs}}[{{.Name}}]({{.Link}}){{end}}。`,
+
+		PRGuide: `***@{{.Author}}*** 你好，
+如果你希望 PR 能被快速检视，请联系以下 SIG。
+{{range .Sigs}}SIG [{{.Name}}]({{.Link}}){{if .Files}}，涉及的文件：{{code .Files}}{{end}}：
+请先联系 owner：{{mention .Owners}}{{if .OtherOwners}}（或 {{join .OtherOwners}}）{{end}} ，
+然后可以联系任意一位 maintainer：{{mention .Maintainers}}
+或者任意一位 committer：{{mention .Committers}}
+{{if .More}}更多成员请查看 [OWNERS]({{.OwnersURL}})
+{{end}}{{end}}`,
+
+		SigNotice: `***@{{.Author}}*** 你好，请使用命令 ***/sig xxx*** 为该 issue 添加 SIG 标签。
+例如：{{range $i, $s := .Examples}}{{if $i}} 或 {{end}}***/sig {{$s}}***{{end}} 等。
+{{if .SigsURL}}可以在[这里]({{.SigsURL}})找到更多的 SIG 标签。
+{{end}}如有疑问，请联系 {{mention .Owners}} 。`,
+
+		Reminder: `{{mention .Owners}} 你好，
+这里已经等待 SIG{{range .Sigs}} [{{.Name}}]({{.Link}}){{end}} 的回复 {{.Days}} 天了，请帮忙看一下。`,
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	"mention": func(ids []string) string {
+		if len(ids) == 0 {
+			return ""
+		}
+
+		return "@" + strings.Join(ids, " , @")
+	},
+	"join": func(ids []string) string {
+		return strings.Join(ids, " , ")
+	},
+	"code": func(files []string) string {
+		return "`" + strings.Join(files, "` , `") + "`"
+	},
+}
+
+type templateSet struct {
+	IssueGuide string `json:"issue_guide,omitempty"`
+	PRGuide    string `json:"pr_guide,omitempty"`
+	SigNotice  string `json:"sig_notice,omitempty"`
+	Reminder   string `json:"reminder,omitempty"`
+}
+
+func (t *templateSet) get(kind string) string {
+	switch kind {
+	case tmplIssueGuide:
+		return t.IssueGuide
+	case tmplPRGuide:
+		return t.PRGuide
+	case tmplSigNotice:
+		return t.SigNotice
+	case tmplReminder:
+		return t.Reminder
+	}
+
+	return ""
+}
+
+// templateFile is the file of templates stored in the relation source.
+// The keys of the maps of templateSet are languages.
+type templateFile struct {
+	Default map[string]templateSet            `json:"default,omitempty"`
+	Sigs    map[string]map[string]templateSet `json:"sigs,omitempty"`
+}
+
+// messageData is the data to render the templates.
+type messageData struct {
+	Author string
+
+	// Owners, Maintainers and Committers are the members of all the sigs.
+	// For the sig notice, Owners are the default owners.
+	// OtherOwners are the owners which are not picked as the first contacts.
+	Owners      []string
+	OtherOwners []string
+	Maintainers []string
+	Committers  []string
+
+	// More means some members are not mentioned, see OwnersURL of Sigs for all of them.
+	More bool
+
+	Sigs []sigData
+
+	// Examples are the names of some sigs, and SigsURL is where to find all of them.
+	// They are only set for the sig notice.
+	Examples []string
+	SigsURL  string
+
+	// Days is how long the item has been waiting for the response, it is only set for the reminder.
+	Days int
+
+	// item is the key of the issue or pull request, the first contacts are picked only if it is set.
+	item string
+
+	// picks are the first contacts picked for the sigs, which are recorded after the guide is posted
+	picks []firstContactPick
+}
+
+type sigData struct {
+	Name        string
+	Label       string
+	Link        string
+	Files       []string
+	Owners      []string
+	OtherOwners []string
+	Maintainers []string
+	Committers  []string
+	More        bool
+	OwnersURL   string
+
+	// defaultOwners means the owners are the default owners, since no owner is found for the files
+	defaultOwners bool
+
+	// allMaintainers are all the maintainers of the sig, which are not deduplicated or capped for mentions
+	allMaintainers []string
+}
+
+// genMessage renders the template of kind. The template is chosen in the order of
+// the templates of botConfig, the templates of the sig and the default templates in the
+// templates file, and the builtin templates. text is used to detect the language
+// if the language is auto.
+func (bot *robot) genMessage(bc *botConfig, kind, text string, data *messageData) (string, error) {
+	lang := bc.Templates.Language
+	if lang == langAuto {
+		lang = detectLanguage(text)
+	}
+
+	data.pickFirstContacts(bot, bc)
+	data.composeMentions(&bc.RelationSource, bc.MentionLimit)
+
+	sigName := ""
+	if len(data.Sigs) == 1 {
+		sigName = data.Sigs[0].Name
+	}
+
+	s, err := bot.findTemplate(bc, kind, lang, sigName)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := template.New(kind).Funcs(templateFuncs).Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (bot *robot) findTemplate(bc *botConfig, kind, lang, sigName string) (string, error) {
+	if v, ok := bc.Templates.Items[lang]; ok && v.get(kind) != "" {
+		return v.get(kind), nil
+	}
+
+	if bc.Templates.Path != "" {
+		f, err := bot.decodeTemplates(&bc.RelationSource, bc.Templates.Path)
+		if err != nil {
+			return "", err
+		}
+
+		if v, ok := f.Sigs[sigName][lang]; ok && v.get(kind) != "" {
+			return v.get(kind), nil
+		}
+
+		if v, ok := f.Default[lang]; ok && v.get(kind) != "" {
+			return v.get(kind), nil
+		}
+	}
+
+	if v, ok := builtinTemplates[lang]; ok {
+		return v.get(kind), nil
+	}
+
+	v := builtinTemplates[langEN]
+
+	return v.get(kind), nil
+}
+
+func (bot *robot) decodeTemplates(src *relationSource, path string) (*templateFile, error) {
+	v, err := bot.cache.get(src.cacheKey("templates", path), func() (interface{}, error) {
+		var f templateFile
+		if err := loadYaml(bot.loader(src), path, &f); err != nil {
+			return nil, err
+		}
+
+		return &f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*templateFile), nil
+}
+
+// detectLanguage returns zh if the text contains any chinese character, otherwise en.
+func detectLanguage(text string) string {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return langZH
+		}
+	}
+
+	return langEN
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/opensourceways/go-gitee/gitee"
+)
+
+// runWhoOwns resolves the files of a repository to the sigs and their owners in the same way
+// as the robot does for a pull request, with the relationship in a local checkout of the tc repository.
+func runWhoOwns(args []string) int {
+	fs := flag.NewFlagSet("who-owns", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s who-owns --repo org/repo [flags] [file ...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	var bc botConfig
+	dir := addLocalRelationFlags(fs, &bc.RelationSource)
+	orgRepo := fs.String("repo", "", "The repository of the files, in the form of org/repo.")
+	diff := fs.String("diff", "", "Path to a unified diff whose changed files are resolved too, - means stdin.")
+	fs.BoolVar(
+		&bc.CustomizeMembers, "customize-members", false,
+		"Take the maintainers and committers from the repositories of OWNERS, the same as customize_members of the robot.",
+	)
+
+	fs.Parse(args)
+	bc.setDefault()
+
+	v := strings.Split(*orgRepo, "/")
+	if len(v) != 2 || v[0] == "" || v[1] == "" {
+		fmt.Fprintln(os.Stderr, "--repo must be in the form of org/repo")
+
+		return 2
+	}
+	org, repo := v[0], v[1]
+
+	files := fs.Args()
+	if *diff != "" {
+		v, err := readDiffFiles(*diff)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+
+			return 1
+		}
+
+		files = append(files, v...)
+	}
+
+	changes := make([]sdk.PullRequestFiles, 0, len(files))
+	for _, f := range files {
+		changes = append(changes, sdk.PullRequestFiles{Filename: f})
+	}
+
+	// the checkout does not change while running, so the files are only parsed once.
+	bot := &robot{cache: newRelationCache(time.Hour), store: &stateStore{}, local: dirLoader(*dir)}
+
+	labels, err := bot.sigLabelsOfChanges(&bc, repo, changes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 1
+	}
+
+	guides, err := bot.genSigGuides(&bc, org, repo, changes, labels)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 1
+	}
+
+	if len(guides) == 0 {
+		fmt.Println("no sig is found")
+
+		return 0
+	}
+
+	for i := range guides {
+		g := &guides[i]
+		fmt.Printf("%s (%s) %s\n", g.Label, g.Name, g.Link)
+		printList("files", g.Files)
+		printList("owners", g.Owners)
+		printList("maintainers", g.Maintainers)
+		printList("committers", g.Committers)
+	}
+
+	return 0
+}
+
+func printList(name string, v []string) {
+	if len(v) > 0 {
+		fmt.Printf("  %-12s %s\n", name+":", strings.Join(v, ", "))
+	}
+}
+
+// readDiffFiles returns the files changed by the unified diff, which is read from stdin if path is -.
+func readDiffFiles(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var files []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		// the name may be followed by a tab and the timestamp
+		f := strings.SplitN(name, "\t", 2)[0]
+		if f == "/dev/null" {
+			return
+		}
+
+		if strings.HasPrefix(f, "a/") || strings.HasPrefix(f, "b/") {
+			f = f[2:]
+		}
+
+		if f != "" && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	prev := ""
+	for s.Scan() {
+		line := s.Text()
+
+		// the header of a file is a --- line followed by a +++ line,
+		// which is not mistaken for the changed lines starting with -- and ++.
+		if strings.HasPrefix(line, "+++ ") && strings.HasPrefix(prev, "--- ") {
+			add(prev[4:])
+			add(line[4:])
+		}
+
+		prev = line
+	}
+
+	return files, s.Err()
+}